@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters used by HashPasswordArgon2.
+// Hashes store their parameters, so changing these does not break verification
+// of existing hashes.
+var (
+	Argon2Memory      uint32 = 64 * 1024 // Memory in KiB
+	Argon2Iterations  uint32 = 1
+	Argon2Parallelism uint8  = 4
+	Argon2SaltLength         = 16
+	Argon2KeyLength   uint32 = 32
+)
+
+/*
+Hashes a password string using Argon2id.
+Returns the hash in the standard self-describing encoding:
+
+	$argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>
+
+where salt and hash are unpadded base64. Verify with CheckPasswordArgon2.
+*/
+func HashPasswordArgon2(password string) (string, error) {
+	salt := make([]byte, Argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, Argon2Iterations, Argon2Memory, Argon2Parallelism, Argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, Argon2Memory, Argon2Iterations, Argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash)), nil
+}
+
+// Compares the password with an Argon2id hash created by HashPasswordArgon2,
+// using the parameters stored in the hash.
+// Returns false if the hash is malformed.
+func CheckPasswordArgon2(password, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+
+	var memory, iterations uint32
+	var parallelism uint8
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism)
+	if err != nil || iterations == 0 || parallelism == 0 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return false
+	}
+
+	other := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(hash)))
+	return subtle.ConstantTimeCompare(hash, other) == 1
+}
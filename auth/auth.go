@@ -1,8 +1,13 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -13,7 +18,18 @@ var ErrInvalidToken = errors.New("jwt token is invalid")
 
 // Hashes a password string using default cost
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return HashPasswordWithCost(password, bcrypt.DefaultCost)
+}
+
+// Hashes a password string using the bcrypt work factor cost.
+// Returns an error if cost is not within bcrypt.MinCost and bcrypt.MaxCost.
+func HashPasswordWithCost(password string, cost int) (string, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return "", fmt.Errorf("auth: bcrypt cost %d is outside the allowed range %d-%d",
+			cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	return string(bytes), err
 }
 
@@ -38,6 +54,12 @@ type JWT struct {
 	secretKey     string            // The SECRET_KEY used by bcrypt to create and verify tokens
 	expireAfter   time.Duration     // Time to expire for the jwt, default: 72 hours
 	signingMethod jwt.SigningMethod // Signing method, default: jwt.SigningMethodHS256
+	refreshWindow time.Duration     // Grace period past exp during which Refresh accepts a token, default: 0
+
+	// Asymmetric keys for RSA and ECDSA signing methods.
+	// The private key is only required to create tokens.
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
 }
 
 type JWTOption func(*JWT)
@@ -49,6 +71,67 @@ func SigningMethod(method jwt.SigningMethod) JWTOption {
 	}
 }
 
+/*
+Sign tokens with privateKey and verify them with publicKey using RS256,
+unless an RSA signing method is already configured with SigningMethod (e.g RS512).
+privateKey may be nil for services that only verify tokens.
+If publicKey is nil, the public key of privateKey is used.
+
+	tokener := auth.NewJWT("", auth.RSAKeys(privateKey, &privateKey.PublicKey))
+*/
+func RSAKeys(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) JWTOption {
+	var signer crypto.Signer
+	if privateKey != nil {
+		signer = privateKey
+	}
+
+	var verifier crypto.PublicKey
+	if publicKey != nil {
+		verifier = publicKey
+	}
+	return asymmetricKeys(jwt.SigningMethodRS256, signer, verifier)
+}
+
+// Sign tokens with privateKey and verify them with publicKey using ES256,
+// unless an ECDSA signing method is already configured with SigningMethod (e.g ES384).
+// privateKey may be nil for services that only verify tokens.
+// If publicKey is nil, the public key of privateKey is used.
+func ECDSAKeys(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey) JWTOption {
+	var signer crypto.Signer
+	if privateKey != nil {
+		signer = privateKey
+	}
+
+	var verifier crypto.PublicKey
+	if publicKey != nil {
+		verifier = publicKey
+	}
+	return asymmetricKeys(jwt.SigningMethodES256, signer, verifier)
+}
+
+// Configures the keys of RSAKeys and ECDSAKeys. The signing method is set to
+// defaultMethod unless one of the same family is already configured.
+// privateKey and publicKey must be untyped nil when not set.
+func asymmetricKeys(defaultMethod jwt.SigningMethod, privateKey crypto.Signer, publicKey crypto.PublicKey) JWTOption {
+	return func(j *JWT) {
+		if reflect.TypeOf(j.signingMethod) != reflect.TypeOf(defaultMethod) {
+			j.signingMethod = defaultMethod
+		}
+
+		verifier := publicKey
+		if privateKey != nil {
+			j.privateKey = privateKey
+			if verifier == nil {
+				verifier = privateKey.Public()
+			}
+		}
+
+		if verifier != nil {
+			j.publicKey = verifier
+		}
+	}
+}
+
 // Configure the expiry of the JWT token.
 func ExpiresAfter(expiresAfter time.Duration) JWTOption {
 	return func(j *JWT) {
@@ -56,10 +139,19 @@ func ExpiresAfter(expiresAfter time.Duration) JWTOption {
 	}
 }
 
-// Creates a new Tokener interface with default expiry of 72 hours.
+// Allow Refresh to re-issue tokens that expired less than d ago.
+// Verify and VerifyClaims are not affected.
+func WithRefreshWindow(d time.Duration) JWTOption {
+	return func(j *JWT) {
+		j.refreshWindow = d
+	}
+}
+
+// Creates a new JWT (which implements Tokener) with default expiry of 72 hours.
 // Customize this by passing in functional options of type JWTOption.
-// secretKey is a required secure token.
-func NewJWT(secretKey string, options ...JWTOption) Tokener {
+// secretKey is a required secure token for HMAC signing methods (the default).
+// It is ignored when signing with RSAKeys or ECDSAKeys.
+func NewJWT(secretKey string, options ...JWTOption) *JWT {
 	jwtoken := &JWT{
 		signingMethod: jwt.SigningMethodHS256,
 		expireAfter:   time.Hour * 72,
@@ -78,11 +170,43 @@ func NewJWT(secretKey string, options ...JWTOption) Tokener {
 // Payload is the id.
 // Returns a base64 encoded JWT string.
 func (jwtoken *JWT) Create(id uint) (string, error) {
-	token := jwt.New(jwtoken.signingMethod)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["id"] = id
-	claims["exp"] = time.Now().Add(jwtoken.expireAfter).Unix()
-	encodedString, err := token.SignedString([]byte(jwtoken.secretKey))
+	return jwtoken.CreateWithExpiry(id, jwtoken.expireAfter)
+}
+
+// Creates a jwt token that expires after d, overriding the configured expiry
+// for this token only. e.g a 15 minute email verification token.
+//
+// Payload is the id.
+// Returns a base64 encoded JWT string.
+func (jwtoken *JWT) CreateWithExpiry(id uint, d time.Duration) (string, error) {
+	return jwtoken.createWithClaims(map[string]any{"id": id}, d)
+}
+
+/*
+Creates a jwt token with arbitrary claims e.g roles, tenant or email.
+The exp claim is set from the configured expiry unless claims already has one.
+claims is not modified.
+
+	token, err := jwtoken.CreateWithClaims(map[string]any{"id": user.ID, "role": "admin"})
+
+Returns a base64 encoded JWT string.
+*/
+func (jwtoken *JWT) CreateWithClaims(claims map[string]any) (string, error) {
+	return jwtoken.createWithClaims(claims, jwtoken.expireAfter)
+}
+
+func (jwtoken *JWT) createWithClaims(claims map[string]any, d time.Duration) (string, error) {
+	mapClaims := make(jwt.MapClaims, len(claims)+1)
+	for key, value := range claims {
+		mapClaims[key] = value
+	}
+
+	if _, ok := mapClaims["exp"]; !ok {
+		mapClaims["exp"] = time.Now().Add(d).Unix()
+	}
+
+	token := jwt.NewWithClaims(jwtoken.signingMethod, mapClaims)
+	encodedString, err := token.SignedString(jwtoken.signingKey())
 	return base64.StdEncoding.EncodeToString([]byte(encodedString)), err
 }
 
@@ -91,25 +215,137 @@ func (jwtoken *JWT) Create(id uint) (string, error) {
 // If the base64Token can not be decoded or an error occurs in jwt.Parse,
 // the error is auth.ErrInvalidToken
 func (jwtoken *JWT) Verify(base64Token string) (uint, error) {
+	id, _, err := jwtoken.VerifyDetailed(base64Token)
+	return id, err
+}
+
+// Like Verify but also returns the expiry of the token from the exp claim.
+// Useful to warn users whose token is about to expire without parsing it again.
+// expiresAt is the zero time if the token has no exp claim.
+func (jwtoken *JWT) VerifyDetailed(base64Token string) (id uint, expiresAt time.Time, err error) {
+	claims, err := jwtoken.VerifyClaims(base64Token)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	idClaim, ok := claims["id"].(float64)
+	if !ok {
+		return 0, time.Time{}, ErrInvalidToken
+	}
+
+	if exp, ok := claims["exp"].(float64); ok {
+		expiresAt = time.Unix(int64(exp), 0)
+	}
+	return uint(idClaim), expiresAt, nil
+}
+
+// Verifies a base64 encoded token string and returns all of its claims.
+// Numeric claims are decoded as float64.
+// If the base64Token can not be decoded, the error is auth.ErrInvalidToken
+func (jwtoken *JWT) VerifyClaims(base64Token string) (jwt.MapClaims, error) {
 	tokenString, err := base64.StdEncoding.DecodeString(base64Token)
 	if err != nil {
-		return 0, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
-	token, err := jwt.Parse(string(tokenString), func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
-		}
-		return []byte(jwtoken.secretKey), nil
-	})
+	token, err := jwt.Parse(string(tokenString), jwtoken.verificationKey)
 
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		id := uint(claims["id"].(float64))
-		return id, nil
+		return claims, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+/*
+Refresh verifies a base64 encoded token and re-issues it with a renewed expiry,
+keeping all other claims (e.g the id). Tokens that expired less than the
+window configured with WithRefreshWindow ago are still accepted.
+
+Returns ErrInvalidToken if the token is malformed, has an invalid signature
+or expired beyond the refresh window.
+*/
+func (jwtoken *JWT) Refresh(base64Token string) (string, error) {
+	tokenString, err := base64.StdEncoding.DecodeString(base64Token)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+
+	// Expiry is checked below against the refresh window.
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	token, err := parser.Parse(string(tokenString), jwtoken.verificationKey)
+	if err != nil || !token.Valid {
+		return "", ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+
+	now := time.Now()
+	if !claims.VerifyExpiresAt(now.Add(-jwtoken.refreshWindow).Unix(), false) ||
+		!claims.VerifyNotBefore(now.Unix(), false) {
+		return "", ErrInvalidToken
+	}
+
+	delete(claims, "exp")
+	return jwtoken.CreateWithClaims(claims)
+}
+
+// Returns the key used to sign tokens with the configured signing method.
+func (jwtoken *JWT) signingKey() interface{} {
+	switch jwtoken.signingMethod.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		return jwtoken.privateKey
+	default:
+		return []byte(jwtoken.secretKey)
+	}
+}
+
+// Returns the key used to verify token.
+// Tokens signed with a different family of algorithms than the configured
+// signing method (e.g HS256 when using RSA keys) are rejected.
+func (jwtoken *JWT) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch jwtoken.signingMethod.(type) {
+	case *jwt.SigningMethodRSA:
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); ok && jwtoken.publicKey != nil {
+			return jwtoken.publicKey, nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok && jwtoken.publicKey != nil {
+			return jwtoken.publicKey, nil
+		}
+	default:
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return []byte(jwtoken.secretKey), nil
+		}
+	}
+	return nil, ErrInvalidToken
+}
+
+/*
+ParseUnverified decodes the claims of a token WITHOUT verifying its signature or expiry.
+
+UNSAFE for authorization decisions: anyone can forge the claims of an unverified token.
+Use it only to inspect claims (e.g the issuer or tenant) needed to choose the key
+to verify the token with, then verify it before trusting any claim.
+
+Accepts base64 encoded tokens as created by JWT.Create and raw JWT strings.
+Returns ErrInvalidToken if the token can not be decoded.
+*/
+func ParseUnverified(token string) (jwt.MapClaims, error) {
+	tokenString := token
+	if decoded, err := base64.StdEncoding.DecodeString(token); err == nil {
+		tokenString = string(decoded)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, claims); err != nil {
+		return nil, ErrInvalidToken
 	}
-	return 0, ErrInvalidToken
+	return claims, nil
 }
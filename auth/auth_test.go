@@ -0,0 +1,448 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordWithCost(t *testing.T) {
+	t.Parallel()
+
+	for _, cost := range []int{bcrypt.MinCost, bcrypt.MinCost + 1} {
+		hash, err := HashPasswordWithCost("secret", cost)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if hashCost, _ := bcrypt.Cost([]byte(hash)); hashCost != cost {
+			t.Errorf("expected cost %d, got %d", cost, hashCost)
+		}
+
+		if !CheckPasswordHash("secret", hash) {
+			t.Errorf("cost %d: expected hash to verify", cost)
+		}
+
+		if CheckPasswordHash("wrong", hash) {
+			t.Errorf("cost %d: expected wrong password to be rejected", cost)
+		}
+	}
+
+	for _, cost := range []int{bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		if _, err := HashPasswordWithCost("secret", cost); err == nil {
+			t.Errorf("expected error for cost %d", cost)
+		}
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	t.Parallel()
+
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cost, _ := bcrypt.Cost([]byte(hash)); cost != bcrypt.DefaultCost {
+		t.Errorf("expected default cost %d, got %d", bcrypt.DefaultCost, cost)
+	}
+
+	if !CheckPasswordHash("secret", hash) {
+		t.Error("expected hash to verify")
+	}
+}
+
+func TestHashPasswordArgon2(t *testing.T) {
+	t.Parallel()
+
+	hash, err := HashPasswordArgon2("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=1,p=4$") {
+		t.Errorf("unexpected hash encoding: %s", hash)
+	}
+
+	if !CheckPasswordArgon2("secret", hash) {
+		t.Error("expected hash to verify")
+	}
+
+	if CheckPasswordArgon2("wrong", hash) {
+		t.Error("expected wrong password to be rejected")
+	}
+
+	other, err := HashPasswordArgon2("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other == hash {
+		t.Error("expected hashes of the same password to use different salts")
+	}
+
+	for _, malformed := range []string{
+		"",
+		"secret",
+		strings.Replace(hash, "argon2id", "argon2i", 1),
+		strings.Replace(hash, "v=19", "v=16", 1),
+		strings.Replace(hash, "t=1", "t=0", 1),
+		hash[:strings.LastIndex(hash, "$")],
+	} {
+		if CheckPasswordArgon2("secret", malformed) {
+			t.Errorf("expected malformed hash %q to be rejected", malformed)
+		}
+	}
+}
+
+// Returns the alg header of a base64 encoded token.
+func tokenAlg(t *testing.T, base64Token string) string {
+	t.Helper()
+
+	raw, err := base64.StdEncoding.DecodeString(base64Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := new(jwt.Parser).ParseUnverified(string(raw), jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.Method.Alg()
+}
+
+func TestAsymmetricSigning(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hmac := NewJWT("secret")
+	tests := []struct {
+		alg      string
+		signer   Tokener
+		verifier Tokener // Verify-only config without a private key.
+	}{
+		{"RS256", NewJWT("", RSAKeys(rsaKey, nil)), NewJWT("", RSAKeys(nil, &rsaKey.PublicKey))},
+		{"ES256", NewJWT("", ECDSAKeys(ecKey, nil)), NewJWT("", ECDSAKeys(nil, &ecKey.PublicKey))},
+	}
+
+	for _, test := range tests {
+		token, err := test.signer.Create(42)
+		if err != nil {
+			t.Fatalf("%s: %v", test.alg, err)
+		}
+
+		if alg := tokenAlg(t, token); alg != test.alg {
+			t.Errorf("expected alg %s, got %s", test.alg, alg)
+		}
+
+		for _, tokener := range []Tokener{test.signer, test.verifier} {
+			if id, err := tokener.Verify(token); err != nil || id != 42 {
+				t.Errorf("%s: expected id 42, got %d %v", test.alg, id, err)
+			}
+		}
+
+		if _, err := test.verifier.Create(1); err == nil {
+			t.Errorf("%s: expected Create to fail without a private key", test.alg)
+		}
+
+		// Tokens of a different algorithm family are rejected both ways.
+		hsToken, err := hmac.Create(42)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := test.verifier.Verify(hsToken); err == nil {
+			t.Errorf("%s: expected HS256 token to be rejected", test.alg)
+		}
+
+		if _, err := hmac.Verify(token); err == nil {
+			t.Errorf("expected %s token to be rejected by an HS256 config", test.alg)
+		}
+	}
+
+	// An HS256 token signed with the public key as the HMAC secret must not verify.
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged, err := NewJWT(string(publicKey)).Create(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tests[0].verifier.Verify(forged); err == nil {
+		t.Error("expected HS256 token signed with the public key to be rejected")
+	}
+
+	esToken, err := tests[1].signer.Create(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tests[0].verifier.Verify(esToken); err == nil {
+		t.Error("expected ES256 token to be rejected by an RSA config")
+	}
+
+	// An RSA signing method configured before the keys is kept.
+	rs512 := NewJWT("", SigningMethod(jwt.SigningMethodRS512), RSAKeys(rsaKey, nil))
+	token, err := rs512.Create(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if alg := tokenAlg(t, token); alg != "RS512" {
+		t.Errorf("expected alg RS512, got %s", alg)
+	}
+}
+
+// Returns token with a character in the middle of its signature changed.
+func tamper(t *testing.T, base64Token string) string {
+	t.Helper()
+
+	raw, err := base64.StdEncoding.DecodeString(base64Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := strings.LastIndex(string(raw), ".") + (len(raw)-strings.LastIndex(string(raw), "."))/2
+	if raw[i] == 'A' {
+		raw[i] = 'B'
+	} else {
+		raw[i] = 'A'
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+
+	tokener := NewJWT("secret", WithRefreshWindow(time.Minute), ExpiresAfter(time.Hour))
+	now := time.Now()
+
+	expired, err := tokener.CreateWithClaims(map[string]any{
+		"id":   7,
+		"role": "admin",
+		"exp":  now.Add(-30 * time.Second).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokener.Verify(expired); err == nil {
+		t.Fatal("expected Verify to reject the expired token")
+	}
+
+	refreshed, err := tokener.Refresh(expired)
+	if err != nil {
+		t.Fatalf("expected token expired within the window to refresh, got %v", err)
+	}
+
+	claims, err := tokener.VerifyClaims(refreshed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims["id"] != float64(7) || claims["role"] != "admin" {
+		t.Errorf("expected claims to be kept, got %v", claims)
+	}
+
+	if exp := int64(claims["exp"].(float64)); exp < now.Add(time.Hour-time.Minute).Unix() {
+		t.Errorf("expected renewed exp in the future, got %v", time.Unix(exp, 0))
+	}
+
+	beyondWindow, err := tokener.CreateWithClaims(map[string]any{"id": 7, "exp": now.Add(-2 * time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, err := tokener.Create(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{
+		"beyond window": beyondWindow,
+		"tampered":      tamper(t, valid),
+		"bad base64":    "not base64!",
+	} {
+		if _, err := tokener.Refresh(token); err != ErrInvalidToken {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	// Without a window, only unexpired tokens are refreshed.
+	if _, err := NewJWT("secret").Refresh(expired); err != ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken without a refresh window, got %v", err)
+	}
+
+	if _, err := tokener.Refresh(valid); err != nil {
+		t.Errorf("expected valid token to refresh, got %v", err)
+	}
+}
+
+func TestCreateWithClaims(t *testing.T) {
+	t.Parallel()
+
+	tokener := NewJWT("secret")
+	claims := map[string]any{"id": 1, "role": "admin", "tenant": "acme"}
+
+	token, err := tokener.CreateWithClaims(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := claims["exp"]; ok || len(claims) != 3 {
+		t.Errorf("expected caller's claims not to be modified, got %v", claims)
+	}
+
+	verified, err := tokener.VerifyClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verified["id"] != float64(1) || verified["role"] != "admin" || verified["tenant"] != "acme" {
+		t.Errorf("unexpected claims: %v", verified)
+	}
+
+	if _, ok := verified["exp"].(float64); !ok {
+		t.Errorf("expected exp claim to be set, got %v", verified["exp"])
+	}
+
+	// A caller-supplied exp is kept.
+	exp := time.Now().Add(5 * time.Minute).Unix()
+	token, err = tokener.CreateWithClaims(map[string]any{"id": 1, "exp": exp})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verified, err = tokener.VerifyClaims(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verified["exp"] != float64(exp) {
+		t.Errorf("expected exp %d, got %v", exp, verified["exp"])
+	}
+
+	if _, err := tokener.VerifyClaims(tamper(t, token)); err == nil {
+		t.Error("expected tampered token to be rejected")
+	}
+
+	if _, err := NewJWT("other").VerifyClaims(token); err == nil {
+		t.Error("expected token signed with another secret to be rejected")
+	}
+}
+
+func TestVerifyDetailed(t *testing.T) {
+	t.Parallel()
+
+	tokener := NewJWT("secret", ExpiresAfter(time.Hour))
+	before := time.Now().Add(time.Hour).Unix()
+
+	token, err := tokener.Create(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, expiresAt, err := tokener.VerifyDetailed(token)
+	if err != nil || id != 9 {
+		t.Fatalf("expected id 9, got %d %v", id, err)
+	}
+
+	if exp := expiresAt.Unix(); exp < before || exp > time.Now().Add(time.Hour).Unix() {
+		t.Errorf("expected expiry in 1 hour, got %v", expiresAt)
+	}
+
+	noID, err := tokener.CreateWithClaims(map[string]any{"role": "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := tokener.VerifyDetailed(noID); err != ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken for token without id, got %v", err)
+	}
+}
+
+func TestCreateWithExpiry(t *testing.T) {
+	t.Parallel()
+
+	tokener := NewJWT("secret", ExpiresAfter(72*time.Hour))
+
+	token, err := tokener.CreateWithExpiry(3, 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, expiresAt, err := tokener.VerifyDetailed(token)
+	if err != nil || id != 3 {
+		t.Fatalf("expected id 3, got %d %v", id, err)
+	}
+
+	if remaining := time.Until(expiresAt); remaining > 15*time.Minute || remaining < 14*time.Minute {
+		t.Errorf("expected per-token expiry of 15 minutes to override ExpiresAfter, got %v", remaining)
+	}
+
+	expired, err := tokener.CreateWithExpiry(3, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tokener.Verify(expired); err == nil {
+		t.Error("expected already expired token to fail Verify")
+	}
+}
+
+func TestParseUnverified(t *testing.T) {
+	t.Parallel()
+
+	token, err := NewJWT("secret").CreateWithClaims(map[string]any{"id": 5, "tenant": "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, input := range map[string]string{
+		"base64":          token,
+		"raw":             string(raw),
+		"wrong signature": tamper(t, token),
+	} {
+		claims, err := ParseUnverified(input)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if claims["id"] != float64(5) || claims["tenant"] != "acme" {
+			t.Errorf("%s: unexpected claims %v", name, claims)
+		}
+	}
+
+	for _, malformed := range []string{"", "not a token", "a.b.c", base64.StdEncoding.EncodeToString([]byte("a.b"))} {
+		if _, err := ParseUnverified(malformed); err != ErrInvalidToken {
+			t.Errorf("%q: expected ErrInvalidToken, got %v", malformed, err)
+		}
+	}
+}
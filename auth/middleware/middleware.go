@@ -2,12 +2,17 @@ package middleware
 
 import (
 	"fmt"
-	"net/http"
 
 	"github.com/abiiranathan/gora/auth"
 	"github.com/abiiranathan/gora/gora"
 )
 
+// Context keys set by the login middleware.
+const (
+	UserKey   = "user"
+	UserIDKey = "userID"
+)
+
 // UserLoader function loads user from the database given the id.
 // Returns the user and an error if user can not be loaded or user is not active.
 type UserLoader[T any] func(userId uint) (user T, err error)
@@ -16,7 +21,9 @@ type UserLoader[T any] func(userId uint) (user T, err error)
 LoginRequired when called with secretKey and userLoader creates a jwt
 middleware that automatically extracts jwt from the request header,
 verifies it fetches the user using the userLoader function and attaches it to the context
-with the key "user". You can access the user downstream from the context and cast it
+with the key "user". You can access the user downstream from the context and cast it.
+The verified user id is also set with the key "userID", see CurrentUserID.
+If userLoader is nil, the user is not loaded (see IDRequired).
 Usage:
 
 	secretKey := os.Getenv("SECRET_KEY")
@@ -29,33 +36,79 @@ Usage:
 	r.Use(AuthMiddleware)
 */
 func LoginRequired[T any](secretKey string, userLoader UserLoader[T]) gora.MiddlewareFunc {
+	return loginRequired(secretKey, userLoader, func(ctx *gora.Context) string {
+		return ctx.BearerToken()
+	})
+}
+
+/*
+LoginRequiredFromCookie is like LoginRequired but reads the token from the
+cookie named cookieName e.g an HttpOnly cookie set at login,
+falling back to the Bearer token in the Authorization header.
+
+	AuthMiddleware := LoginRequiredFromCookie(secretKey, "token", userLoader)
+*/
+func LoginRequiredFromCookie[T any](secretKey, cookieName string, userLoader UserLoader[T]) gora.MiddlewareFunc {
+	return loginRequired(secretKey, userLoader, func(ctx *gora.Context) string {
+		if token, err := ctx.Cookie(cookieName); err == nil && token != "" {
+			return token
+		}
+		return ctx.BearerToken()
+	})
+}
+
+// IDRequired verifies the Bearer token like LoginRequired without loading the user.
+// Only the user id is set on the context. Use it on hot paths where handlers
+// need the id but not the full user record.
+func IDRequired(secretKey string) gora.MiddlewareFunc {
+	return LoginRequired[any](secretKey, nil)
+}
+
+// Returns the user id verified by the login middleware.
+// ok is false if the request was not authenticated by it.
+func CurrentUserID(c *gora.Context) (id uint, ok bool) {
+	value, ok := c.Get(UserIDKey)
+	if !ok {
+		return 0, false
+	}
+	id, ok = value.(uint)
+	return id, ok
+}
+
+func loginRequired[T any](secretKey string, userLoader UserLoader[T], getToken func(ctx *gora.Context) string) gora.MiddlewareFunc {
 	tokener := auth.NewJWT(secretKey)
 
 	return func(next gora.HandlerFunc) gora.HandlerFunc {
 		return func(ctx *gora.Context) {
-			// Get the Bearer token from the request
-			token := ctx.BearerToken()
+			// Get the token from the request
+			token := getToken(ctx)
 			if token == "" {
-				ctx.Abort(http.StatusUnauthorized, "Unauthorized")
+				ctx.Unauthorized("Unauthorized")
 				return
 			}
 
 			// Verify the token
 			userId, err := tokener.Verify(token)
 			if err != nil {
-				ctx.Abort(http.StatusUnauthorized, fmt.Sprintf("Unauthorized: %s", err.Error()))
+				ctx.Unauthorized(fmt.Sprintf("Unauthorized: %s", err.Error()))
+				return
+			}
+
+			ctx.Set(UserIDKey, userId)
+			if userLoader == nil {
+				next(ctx)
 				return
 			}
 
 			// Fetch user by id
 			user, err := userLoader(userId)
 			if err != nil {
-				ctx.Abort(http.StatusForbidden, "Forbidden: User not found!")
+				ctx.Forbidden("Forbidden: User not found!")
 				return
 			}
 
 			// Set user in the context
-			ctx.Set("user", user)
+			ctx.Set(UserKey, user)
 			next(ctx)
 		}
 	}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abiiranathan/gora/auth"
+	"github.com/abiiranathan/gora/gora"
+)
+
+const testSecret = "secret"
+
+func testToken(t *testing.T, id uint) string {
+	t.Helper()
+
+	token, err := auth.NewJWT(testSecret).Create(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestLoginRequiredFromCookie(t *testing.T) {
+	t.Parallel()
+
+	loader := func(id uint) (string, error) {
+		if id != 1 {
+			return "", errors.New("user not found")
+		}
+		return "alice", nil
+	}
+
+	r := gora.New(io.Discard)
+	r.GET("/me", func(ctx *gora.Context) {
+		ctx.String(ctx.MustGet(UserKey).(string))
+	}, LoginRequiredFromCookie(testSecret, "token", loader))
+
+	token := testToken(t, 1)
+	tests := []struct {
+		name     string
+		cookie   string
+		header   string
+		expected int
+	}{
+		{"valid cookie", token, "", http.StatusOK},
+		{"authorization header fallback", "", "Bearer " + token, http.StatusOK},
+		{"missing token", "", "", http.StatusUnauthorized},
+		{"invalid cookie", "invalid", "", http.StatusUnauthorized},
+		{"invalid header", "", "Bearer invalid", http.StatusUnauthorized},
+		{"unknown user", testToken(t, 2), "", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: test.cookie})
+		}
+		if test.header != "" {
+			req.Header.Set("Authorization", test.header)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.expected, w.Code)
+		}
+
+		if test.expected == http.StatusOK && w.Body.String() != "alice" {
+			t.Errorf("%s: expected user alice, got %q", test.name, w.Body.String())
+		}
+	}
+}
+
+func TestIDRequired(t *testing.T) {
+	t.Parallel()
+
+	loaderCalls := 0
+	loader := func(id uint) (string, error) {
+		loaderCalls++
+		return "alice", nil
+	}
+
+	handler := func(ctx *gora.Context) {
+		id, ok := CurrentUserID(ctx)
+		if !ok {
+			ctx.Abort(http.StatusInternalServerError, "user id not set")
+			return
+		}
+
+		if _, loaded := ctx.Get(UserKey); loaded {
+			ctx.Abort(http.StatusInternalServerError, "user loaded")
+			return
+		}
+		ctx.JSON(id)
+	}
+
+	r := gora.New(io.Discard)
+	r.GET("/id", handler, IDRequired(testSecret))
+	r.GET("/user", func(ctx *gora.Context) {
+		id, _ := CurrentUserID(ctx)
+		ctx.JSON(gora.Map{"id": id, "user": ctx.MustGet(UserKey)})
+	}, LoginRequired(testSecret, loader))
+	r.GET("/public", func(ctx *gora.Context) {
+		if _, ok := CurrentUserID(ctx); ok {
+			ctx.Abort(http.StatusInternalServerError, "unexpected user id")
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, 7))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "7" {
+		t.Errorf("expected user id 7, got %d %q", w.Code, w.Body.String())
+	}
+
+	if loaderCalls != 0 {
+		t.Errorf("expected IDRequired not to load the user, got %d calls", loaderCalls)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/id", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without a token, got %d", w.Code)
+	}
+
+	// LoginRequired sets both the id and the loaded user.
+	req = httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken(t, 7))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Body.String() != `{"id":7,"user":"alice"}` || loaderCalls != 1 {
+		t.Errorf("expected id and loaded user, got %q with %d loader calls", w.Body.String(), loaderCalls)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/public", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected CurrentUserID to report no user on unauthenticated routes, got %d", w.Code)
+	}
+}
@@ -61,10 +61,13 @@ func main() {
 	r.GET("/ws", gora.WrapH(hub))
 
 	// r.Static("/", ".", "")
-	r.StaticEmbedFS(gora.StaticEmbed{
+	err := r.StaticEmbedFS(gora.StaticEmbed{
 		EmbedFS:        &build,
 		Route:          "/",
 		Dirname:        "build",
 		IgnorePatterns: []string{"/api", "/ws"}})
+	if err != nil {
+		r.Logger.Warn().Err(err).Msg("frontend build not found, serving API only")
+	}
 	r.Run(":8080")
 }
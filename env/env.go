@@ -4,13 +4,19 @@ Package env provides functions for loading and parsing configuration files in th
 The '.env' format is a simple key-value format, with one key-value pair per line.
 Lines that begin with a '#' character are treated as comments and are ignored.
 Keys and values may be surrounded by quotes, but this is not required.
+Values may reference keys defined on prior lines or environment variables
+with ${VAR}, e.g DB_URL=postgres://${DB_HOST}:5432.
+Single-quoted values are taken literally, without expanding ${VAR}.
 
 The LoadConfig function can be used to load a configuration file and parse its
 key-value pairs into a struct or map. Converter functions can be provided to
 specify how each key's value should be parsed.
+LoadConfigReader parses from any io.Reader and LoadConfigFiles merges
+several files, with later files overriding earlier ones.
 
 The LoadEnv function can be used to load a configuration file and set
 the corresponding environment variables for the current process.
+Save writes a config struct or map back to a file in the same format.
 */
 package env
 
@@ -19,102 +25,465 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 /*
 The config struct is passed as an argument and the LoadConfig function uses
 reflection to examine the fields of the struct and compare the key name with
-the "name" tag. If a match is found, it uses a switch statement to check the
+the "name" tag (or "key" tag). If a match is found, it uses a switch statement to check the
 field's Kind and parse the value accordingly.
+Keys missing from the file are set from the field's "default" tag if any,
+otherwise fields tagged required:"true" cause an error.
 If the fields are not primitive types or the key is not found in the struct,
 it returns an error.
+
+config may also be a map[string]interface{}, which receives every key in the file.
+Use WithConverters to parse the values of specific keys, e.g into ints.
 */
-func LoadConfig(filename string, config interface{}) error {
+func LoadConfig(filename string, config interface{}, options ...Option) error {
 	file, err := os.Open(filename)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	keys := make(map[string]bool)
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "#") {
-			continue
+	return LoadConfigReader(file, config, options...)
+}
+
+// LoadConfigReader is like LoadConfig but parses the '.env' formatted
+// key-value pairs from r. Useful for embedded config files and test fixtures.
+func LoadConfigReader(r io.Reader, config interface{}, options ...Option) error {
+	pairs, err := parseEnv(r)
+	if err != nil {
+		return err
+	}
+	return setConfig(config, pairs, options...)
+}
+
+// LoadConfigFiles loads and merges multiple configuration files into config.
+// Files are applied in order so that keys in later files override
+// the same keys in earlier files. e.g a base '.env' followed by '.env.production'.
+// Required fields are satisfied if the key is present in any of the files.
+// ${VAR} references resolve keys from earlier lines of the same or earlier files.
+func LoadConfigFiles(config interface{}, filenames []string, options ...Option) error {
+	var pairs []KeyValuePair
+	resolved := make(map[string]string)
+	for _, filename := range filenames {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
 		}
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
+
+		filePairs, err := parseEnvResolved(file, resolved)
+		file.Close()
+		if err != nil {
+			return err
 		}
+		pairs = append(pairs, filePairs...)
+	}
+	return setConfig(config, pairs, options...)
+}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-		keys[key] = true
+// Option configures how LoadConfig, LoadConfigReader and LoadConfigFiles load the config.
+type Option func(*loadOptions)
+
+type loadOptions struct {
+	envOverride bool                       // Process environment takes precedence over file values
+	converters  map[string]ConfigConverter // Per-key value converters
+}
+
+// ConfigConverter parses the raw value of a key into the value stored in the config.
+type ConfigConverter func(value string) (interface{}, error)
+
+// WithEnvOverride when true makes environment variables take precedence
+// over values in the file. After parsing, each key named by a "name" tag
+// is looked up with os.LookupEnv and the environment value is used if present.
+func WithEnvOverride(override bool) Option {
+	return func(o *loadOptions) {
+		o.envOverride = override
+	}
+}
+
+/*
+WithConverters parses the values of the given keys with their converter.
+Converters are required to load into a map[string]interface{}
+for values other than strings, as keys without a converter are stored as strings.
+For structs, the converted value must be assignable to the field.
+
+	config := map[string]interface{}{}
+	err := env.LoadConfig(".env", config, env.WithConverters(map[string]env.ConfigConverter{
+		"PORT": func(v string) (interface{}, error) { return strconv.Atoi(v) },
+	}))
+*/
+func WithConverters(converters map[string]ConfigConverter) Option {
+	return func(o *loadOptions) {
+		o.converters = converters
+	}
+}
+
+// Returns the key of a struct field from its "name" tag,
+// falling back to the "key" tag.
+func fieldKey(f reflect.StructField) string {
+	if key := f.Tag.Get("name"); key != "" {
+		return key
+	}
+	return f.Tag.Get("key")
+}
+
+// setConfig sets the fields of the config struct (or the keys of a map)
+// from the key-value pairs and checks that all required fields are present.
+// Pairs are applied in order so later keys override earlier ones.
+func setConfig(config interface{}, pairs []KeyValuePair, options ...Option) error {
+	opts := &loadOptions{}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	v := reflect.ValueOf(config)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Map {
+		return setMap(v, pairs, opts)
+	}
 
-		v := reflect.ValueOf(config)
-		if v.Kind() == reflect.Ptr {
-			v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("config must be a struct or map, got %s", v.Kind())
+	}
+
+	if opts.envOverride {
+		for i := 0; i < v.NumField(); i++ {
+			key := fieldKey(v.Type().Field(i))
+			if key == "" {
+				continue
+			}
+
+			if value, ok := os.LookupEnv(key); ok {
+				pairs = append(pairs, KeyValuePair{Key: key, Value: value})
+			}
 		}
+	}
+
+	keys := make(map[string]bool)
+	for _, pair := range pairs {
+		key, value := pair.Key, pair.Value
+		keys[key] = true
 
 		for i := 0; i < v.NumField(); i++ {
 			f := v.Type().Field(i)
-			if key == f.Tag.Get("name") {
-				field := v.Field(i)
-				switch field.Kind() {
-				case reflect.String:
-					field.SetString(value)
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					intValue, err := strconv.ParseInt(value, 10, 64)
-					if err != nil {
-						return fmt.Errorf("invalid value for %s: %v", key, err)
-					}
-					field.SetInt(intValue)
-				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-					uintValue, err := strconv.ParseUint(value, 10, 64)
-					if err != nil {
-						return fmt.Errorf("invalid value for %s: %v", key, err)
-					}
-					field.SetUint(uintValue)
-				case reflect.Float32, reflect.Float64:
-					floatValue, err := strconv.ParseFloat(value, 64)
-					if err != nil {
-						return fmt.Errorf("invalid value for %s: %v", key, err)
-					}
-					field.SetFloat(floatValue)
-				case reflect.Bool:
-					boolValue, err := strconv.ParseBool(value)
-					if err != nil {
-						return fmt.Errorf("invalid value for %s: %v", key, err)
-					}
-					field.SetBool(boolValue)
-				default:
-					return fmt.Errorf("unsupported type for field %s", f.Name)
+			if key == fieldKey(f) {
+				if err := setField(v.Field(i), f, key, value, opts.converters[key]); err != nil {
+					return err
 				}
 				break
 			}
 		}
 	}
 
-	if err := scanner.Err(); err != nil {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		key := fieldKey(f)
+		if keys[key] {
+			continue
+		}
+
+		// Fields with a default are set from it and satisfy the required check.
+		if value, ok := f.Tag.Lookup("default"); ok {
+			if err := setField(v.Field(i), f, key, value, opts.converters[key]); err != nil {
+				return fmt.Errorf("invalid default: %w", err)
+			}
+			continue
+		}
+
+		if f.Tag.Get("required") == "true" {
+			return fmt.Errorf("missing required field %s", key)
+		}
+	}
+	return nil
+}
+
+// setMap stores the pairs in a map[string]interface{} (or map[string]string),
+// converting values with the key's converter if any.
+func setMap(v reflect.Value, pairs []KeyValuePair, opts *loadOptions) error {
+	if v.IsNil() {
+		return fmt.Errorf("config map must not be nil")
+	}
+
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("config map keys must be strings, got %s", v.Type().Key())
+	}
+
+	if opts.envOverride {
+		for _, pair := range pairs {
+			if value, ok := os.LookupEnv(pair.Key); ok {
+				pairs = append(pairs, KeyValuePair{Key: pair.Key, Value: value})
+			}
+		}
+	}
+
+	elemType := v.Type().Elem()
+	for _, pair := range pairs {
+		var value interface{} = pair.Value
+		if converter := opts.converters[pair.Key]; converter != nil {
+			converted, err := converter(pair.Value)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %v", pair.Key, err)
+			}
+			value = converted
+		}
+
+		rv := reflect.ValueOf(value)
+		if !rv.IsValid() {
+			rv = reflect.Zero(elemType)
+		}
+
+		if !rv.Type().AssignableTo(elemType) {
+			return fmt.Errorf("value of type %s for %s is not assignable to %s", rv.Type(), pair.Key, elemType)
+		}
+		v.SetMapIndex(reflect.ValueOf(pair.Key).Convert(v.Type().Key()), rv)
+	}
+	return nil
+}
+
+// setField parses value into the struct field f, using converter if not nil.
+func setField(field reflect.Value, f reflect.StructField, key, value string, converter ConfigConverter) error {
+	if converter != nil {
+		converted, err := converter(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+
+		rv := reflect.ValueOf(converted)
+		if !rv.IsValid() {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+
+		if !rv.Type().AssignableTo(field.Type()) {
+			return fmt.Errorf("value of type %s for %s is not assignable to field %s", rv.Type(), key, f.Name)
+		}
+		field.Set(rv)
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice {
+		return setSlice(field, f, key, value)
+	}
+	return setValue(field, f, key, value)
+}
+
+// setSlice splits value on commas into a slice of strings, ints, uints, floats or bools.
+// Elements are trimmed of whitespace. An empty value yields an empty, non-nil slice.
+func setSlice(field reflect.Value, f reflect.StructField, key, value string) error {
+	var parts []string
+	if strings.TrimSpace(value) != "" {
+		parts = strings.Split(value, ",")
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		if err := setValue(slice.Index(i), f, key, strings.TrimSpace(part)); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setValue parses value into field of a primitive kind or time.Duration.
+func setValue(field reflect.Value, f reflect.StructField, key, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		intValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+		field.SetInt(intValue)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		uintValue, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+		field.SetUint(uintValue)
+	case reflect.Float32, reflect.Float64:
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+		field.SetFloat(floatValue)
+	case reflect.Bool:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+		field.SetBool(boolValue)
+	default:
+		return fmt.Errorf("unsupported type for field %s", f.Name)
+	}
+	return nil
+}
+
+/*
+Save writes config, a struct (or pointer to one) or a map with string keys,
+to filename in the '.env' format, one KEY=value line per field or key.
+Struct fields are written in order using their "name" (or "key") tag and
+fields without one are skipped. Map keys are written in sorted order.
+Slices are written comma-separated and values with spaces or special
+characters are quoted, so that the file can be loaded back with LoadConfig.
+Values containing ${ are single-quoted so that they are not interpolated on load.
+
+The file is written atomically: to a temporary file in the same directory
+that is renamed to filename. An existing file keeps its permissions.
+*/
+func Save(filename string, config interface{}) error {
+	pairs, err := configPairs(config)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, pair := range pairs {
+		value, err := quoteValue(pair.Value)
+		if err != nil {
+			return fmt.Errorf("can not save %s: %w", pair.Key, err)
+		}
+
+		b.WriteString(pair.Key)
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.WriteString(b.String()); err != nil {
+		tmp.Close()
 		return err
 	}
 
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// configPairs returns the key-value pairs of a config struct or map.
+func configPairs(config interface{}) ([]KeyValuePair, error) {
 	v := reflect.ValueOf(config)
 	if v.Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
 
-	for i := 0; i < v.NumField(); i++ {
-		f := v.Type().Field(i)
-		if f.Tag.Get("required") == "true" && !keys[f.Tag.Get("name")] {
-			return fmt.Errorf("missing required field %s", f.Tag.Get("name"))
+	var pairs []KeyValuePair
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			key := fieldKey(v.Type().Field(i))
+			if key == "" {
+				continue
+			}
+			pairs = append(pairs, KeyValuePair{Key: key, Value: formatValue(v.Field(i))})
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("config map keys must be strings, got %s", v.Type().Key())
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			pairs = append(pairs, KeyValuePair{Key: iter.Key().String(), Value: formatValue(iter.Value())})
 		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	default:
+		return nil, fmt.Errorf("config must be a struct or map, got %s", v.Kind())
 	}
-	return nil
+	return pairs, nil
+}
+
+// formatValue formats v as LoadConfig parses it. Slices are comma-separated.
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	}
+
+	if !v.IsValid() || (v.Kind() == reflect.Interface && v.IsNil()) {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// quoteValue quotes value if it contains spaces or characters
+// that would change how it is parsed. Values containing ${ are single-quoted
+// so that they are not interpolated when loaded.
+// Returns an error if such a value also contains a single quote or newline,
+// which can not be represented in a single-quoted value.
+func quoteValue(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+
+	if strings.Contains(value, "${") {
+		if strings.ContainsAny(value, "'\r\n") {
+			return "", fmt.Errorf("value %q contains ${ and a single quote or newline", value)
+		}
+		return "'" + value + "'", nil
+	}
+
+	if strings.ContainsAny(value, " \t\r\n\"'#\\") || strings.TrimSpace(value) != value {
+		return strconv.Quote(value), nil
+	}
+	return value, nil
 }
 
 // LoadEnv loads the key-value pairs from a configuration file in the '.env'
@@ -152,9 +521,17 @@ func LoadEnv(filename string) error {
 // parseEnv parses the key-value pairs from an '.env' file. Lines that begin
 // with a '#' character are treated as comments and are ignored. Keys and
 // values may be surrounded by quotes, but this is not required.
+// ${VAR} references in values are expanded, see interpolate,
+// except in single-quoted values.
 //
 // If the file cannot be read, an error is returned.
 func parseEnv(r io.Reader) ([]KeyValuePair, error) {
+	return parseEnvResolved(r, make(map[string]string))
+}
+
+// Like parseEnv but interpolates from and records into resolved,
+// the values of prior lines, e.g to share them across files.
+func parseEnvResolved(r io.Reader, resolved map[string]string) ([]KeyValuePair, error) {
 	var pairs []KeyValuePair
 
 	scanner := bufio.NewScanner(r)
@@ -174,16 +551,22 @@ func parseEnv(r io.Reader) ([]KeyValuePair, error) {
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
 
-		// Unquote the value if necessary
-		if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+		// Unquote the value if necessary. Single-quoted values are literal.
+		switch {
+		case len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"':
 			v, err := strconv.Unquote(value)
 			if err != nil {
 				return nil, err
 			}
 
-			value = v
+			value = interpolate(v, resolved)
+		default:
+			value = interpolate(value, resolved)
 		}
 
+		resolved[key] = value
 		pairs = append(pairs, KeyValuePair{Key: key, Value: value})
 	}
 	if err := scanner.Err(); err != nil {
@@ -193,6 +576,38 @@ func parseEnv(r io.Reader) ([]KeyValuePair, error) {
 	return pairs, nil
 }
 
+// interpolate expands ${VAR} references in value from the keys parsed on
+// prior lines, falling back to the process environment.
+// Unresolved references expand to an empty string.
+// e.g DB_URL=postgres://${DB_HOST}:5432
+func interpolate(value string, resolved map[string]string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(value[:start])
+		name := value[start+2 : end]
+		if v, ok := resolved[name]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(os.Getenv(name))
+		}
+		value = value[end+1:]
+	}
+
+	b.WriteString(value)
+	return b.String()
+}
+
 // KeyValuePair represents a key-value pair in an '.env' file.
 type KeyValuePair struct {
 	Key   string
@@ -1,9 +1,14 @@
 package env
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -82,3 +87,426 @@ func TestLoadConfigWithRequiredTag(t *testing.T) {
 		t.Errorf("Expected missing required field error for KEY3, got %v", err)
 	}
 }
+
+func TestLoadConfigReader(t *testing.T) {
+	type Config struct {
+		Host string `name:"HOST"`
+		Port int    `name:"PORT" required:"true"`
+	}
+
+	config := &Config{}
+	err := LoadConfigReader(strings.NewReader("# comment\nHOST=\"localhost\"\nPORT=8080\n"), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Host != "localhost" {
+		t.Errorf("Expected Host to be 'localhost', got %s", config.Host)
+	}
+	if config.Port != 8080 {
+		t.Errorf("Expected Port to be 8080, got %d", config.Port)
+	}
+
+	err = LoadConfigReader(strings.NewReader("HOST=localhost\n"), &Config{})
+	if err == nil || !strings.Contains(err.Error(), "missing required field PORT") {
+		t.Errorf("Expected missing required field error for PORT, got %v", err)
+	}
+}
+
+func TestLoadConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	override := filepath.Join(dir, ".env.production")
+
+	if err := os.WriteFile(base, []byte("HOST=localhost\nPORT=8080\nDEBUG=true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte("HOST=example.com\nDEBUG=false\nURL=http://${HOST}:${PORT}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	type Config struct {
+		Host  string `name:"HOST"`
+		Port  int    `name:"PORT" required:"true"`
+		Debug bool   `name:"DEBUG"`
+		URL   string `name:"URL"`
+	}
+
+	config := &Config{}
+	if err := LoadConfigFiles(config, []string{base, override}); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.URL != "http://example.com:8080" {
+		t.Errorf("Expected URL to interpolate keys across files, got %s", config.URL)
+	}
+
+	if config.Host != "example.com" {
+		t.Errorf("Expected Host to be overridden to 'example.com', got %s", config.Host)
+	}
+	if config.Port != 8080 {
+		t.Errorf("Expected Port from base file to be 8080, got %d", config.Port)
+	}
+	if config.Debug {
+		t.Errorf("Expected Debug to be overridden to false")
+	}
+
+	t.Setenv("PORT", "9090")
+	config = &Config{}
+	if err := LoadConfigFiles(config, []string{base, override}, WithEnvOverride(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Port != 9090 {
+		t.Errorf("Expected options to apply to LoadConfigFiles, got Port %d", config.Port)
+	}
+}
+
+func TestLoadConfigWithEnvOverride(t *testing.T) {
+	t.Setenv("HOST", "db.internal")
+	t.Setenv("PORT", "5432")
+
+	type Config struct {
+		Host string `name:"HOST"`
+		Port int    `name:"PORT"`
+		User string `name:"USER_NAME"`
+	}
+
+	src := "HOST=localhost\nUSER_NAME=admin\n"
+
+	config := &Config{}
+	if err := LoadConfigReader(strings.NewReader(src), config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "localhost" || config.Port != 0 {
+		t.Errorf("Expected file values without override, got %+v", config)
+	}
+
+	config = &Config{}
+	if err := LoadConfigReader(strings.NewReader(src), config, WithEnvOverride(true)); err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "db.internal" {
+		t.Errorf("Expected Host from environment, got %s", config.Host)
+	}
+	if config.Port != 5432 {
+		t.Errorf("Expected Port from environment, got %d", config.Port)
+	}
+	if config.User != "admin" {
+		t.Errorf("Expected User from file, got %s", config.User)
+	}
+}
+
+func TestLoadConfigWithConverters(t *testing.T) {
+	src := "HOST=localhost\nPORT=8080\nTIMEOUT=5s\n"
+	converters := map[string]ConfigConverter{
+		"PORT": func(v string) (interface{}, error) { return strconv.Atoi(v) },
+		"TIMEOUT": func(v string) (interface{}, error) {
+			return time.ParseDuration(v)
+		},
+	}
+
+	config := map[string]interface{}{}
+	if err := LoadConfigReader(strings.NewReader(src), config, WithConverters(converters)); err != nil {
+		t.Fatal(err)
+	}
+
+	if config["HOST"] != "localhost" {
+		t.Errorf("Expected HOST to default to the string 'localhost', got %v", config["HOST"])
+	}
+	if config["PORT"] != 8080 {
+		t.Errorf("Expected PORT to be converted to 8080, got %#v", config["PORT"])
+	}
+	if config["TIMEOUT"] != 5*time.Second {
+		t.Errorf("Expected TIMEOUT to be converted to 5s, got %#v", config["TIMEOUT"])
+	}
+
+	type Config struct {
+		Host    string        `name:"HOST"`
+		Timeout time.Duration `name:"TIMEOUT"`
+	}
+
+	structConfig := &Config{}
+	if err := LoadConfigReader(strings.NewReader(src), structConfig, WithConverters(converters)); err != nil {
+		t.Fatal(err)
+	}
+	if structConfig.Host != "localhost" || structConfig.Timeout != 5*time.Second {
+		t.Errorf("Expected converted struct fields, got %+v", structConfig)
+	}
+
+	failing := map[string]ConfigConverter{
+		"PORT": func(v string) (interface{}, error) { return nil, errors.New("bad port") },
+	}
+	err := LoadConfigReader(strings.NewReader(src), map[string]interface{}{}, WithConverters(failing))
+	if err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Errorf("Expected converter error naming PORT, got %v", err)
+	}
+
+	values := map[string]string{}
+	if err := LoadConfigReader(strings.NewReader(src), values); err != nil {
+		t.Fatal(err)
+	}
+	if values["PORT"] != "8080" {
+		t.Errorf("Expected PORT to be the string '8080', got %q", values["PORT"])
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	t.Setenv("GORA_TEST_DB_USER", "admin")
+
+	src := strings.Join([]string{
+		"DB_HOST=localhost",
+		"DB_ADDR=${DB_HOST}:5432",
+		`DB_URL="postgres://${GORA_TEST_DB_USER}@${DB_ADDR}/app"`,
+		"MISSING=a${GORA_TEST_MISSING}b",
+		"UNTERMINATED=${DB_HOST",
+		"PASSWORD=pa$$word",
+	}, "\n")
+
+	config := map[string]string{}
+	if err := LoadConfigReader(strings.NewReader(src), config); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"DB_ADDR":      "localhost:5432",
+		"DB_URL":       "postgres://admin@localhost:5432/app",
+		"MISSING":      "ab",
+		"UNTERMINATED": "${DB_HOST",
+		"PASSWORD":     "pa$$word",
+	}
+
+	for key, value := range expected {
+		if config[key] != value {
+			t.Errorf("Expected %s to be %q, got %q", key, value, config[key])
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(file, []byte("GORA_TEST_HOST=db\nGORA_TEST_URL=${GORA_TEST_HOST}:5432\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GORA_TEST_HOST", "")
+	t.Setenv("GORA_TEST_URL", "")
+	if err := LoadEnv(file); err != nil {
+		t.Fatal(err)
+	}
+	if url := os.Getenv("GORA_TEST_URL"); url != "db:5432" {
+		t.Errorf("Expected LoadEnv to set interpolated value 'db:5432', got %q", url)
+	}
+}
+
+func TestLoadConfigSlices(t *testing.T) {
+	type Config struct {
+		Hosts   []string  `name:"ALLOWED_HOSTS"`
+		Ports   []int     `name:"PORTS"`
+		Weights []float64 `name:"WEIGHTS"`
+		Flags   []bool    `name:"FLAGS"`
+		Empty   []string  `name:"EMPTY"`
+	}
+
+	src := "ALLOWED_HOSTS=a.com, b.com ,c.com\nPORTS=80,443\nWEIGHTS=0.5,1.5\nFLAGS=true,false\nEMPTY=\n"
+
+	config := &Config{}
+	if err := LoadConfigReader(strings.NewReader(src), config); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(config.Hosts, "|") != "a.com|b.com|c.com" {
+		t.Errorf("Expected trimmed hosts, got %q", config.Hosts)
+	}
+	if len(config.Ports) != 2 || config.Ports[0] != 80 || config.Ports[1] != 443 {
+		t.Errorf("Expected ports [80 443], got %v", config.Ports)
+	}
+	if len(config.Weights) != 2 || config.Weights[1] != 1.5 {
+		t.Errorf("Expected weights [0.5 1.5], got %v", config.Weights)
+	}
+	if len(config.Flags) != 2 || !config.Flags[0] || config.Flags[1] {
+		t.Errorf("Expected flags [true false], got %v", config.Flags)
+	}
+	if config.Empty == nil || len(config.Empty) != 0 {
+		t.Errorf("Expected an empty non-nil slice, got %#v", config.Empty)
+	}
+
+	err := LoadConfigReader(strings.NewReader("PORTS=80,http\n"), &Config{})
+	if err == nil || !strings.Contains(err.Error(), "PORTS") {
+		t.Errorf("Expected error naming PORTS, got %v", err)
+	}
+}
+
+func TestLoadConfigDuration(t *testing.T) {
+	type Config struct {
+		ReadTimeout  time.Duration   `name:"READ_TIMEOUT"`
+		WriteTimeout time.Duration   `name:"WRITE_TIMEOUT"`
+		Backoff      []time.Duration `name:"BACKOFF"`
+	}
+
+	config := &Config{}
+	src := "READ_TIMEOUT=1h30m\nWRITE_TIMEOUT=500ms\nBACKOFF=1s, 2s\n"
+	if err := LoadConfigReader(strings.NewReader(src), config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.ReadTimeout != 90*time.Minute {
+		t.Errorf("Expected ReadTimeout to be 1h30m, got %s", config.ReadTimeout)
+	}
+	if config.WriteTimeout != 500*time.Millisecond {
+		t.Errorf("Expected WriteTimeout to be 500ms, got %s", config.WriteTimeout)
+	}
+	if len(config.Backoff) != 2 || config.Backoff[1] != 2*time.Second {
+		t.Errorf("Expected Backoff [1s 2s], got %v", config.Backoff)
+	}
+
+	err := LoadConfigReader(strings.NewReader("READ_TIMEOUT=30\n"), &Config{})
+	if err == nil || !strings.Contains(err.Error(), "READ_TIMEOUT") {
+		t.Errorf("Expected error naming READ_TIMEOUT for a duration without unit, got %v", err)
+	}
+}
+
+func TestLoadConfigDefaultTag(t *testing.T) {
+	type Config struct {
+		Host    string `name:"HOST" default:"localhost"`
+		Port    int    `name:"PORT" default:"8080" required:"true"`
+		Debug   bool   `name:"DEBUG" default:"true"`
+		Workers int    `name:"WORKERS" default:"4"`
+	}
+
+	config := &Config{}
+	if err := LoadConfigReader(strings.NewReader("WORKERS=8\n"), config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Host != "localhost" || config.Port != 8080 || !config.Debug {
+		t.Errorf("Expected defaults for missing keys, got %+v", config)
+	}
+	if config.Workers != 8 {
+		t.Errorf("Expected WORKERS from the file to override the default, got %d", config.Workers)
+	}
+
+	type Required struct {
+		Port   int    `name:"PORT" default:"8080" required:"true"`
+		Secret string `name:"SECRET" required:"true"`
+	}
+
+	err := LoadConfigReader(strings.NewReader(""), &Required{})
+	if err == nil || !strings.Contains(err.Error(), "missing required field SECRET") {
+		t.Errorf("Expected missing required field error for SECRET, got %v", err)
+	}
+
+	type Invalid struct {
+		Port int `name:"PORT" default:"http"`
+	}
+
+	err = LoadConfigReader(strings.NewReader(""), &Invalid{})
+	if err == nil || !strings.Contains(err.Error(), "PORT") {
+		t.Errorf("Expected invalid default error naming PORT, got %v", err)
+	}
+}
+
+func TestSave(t *testing.T) {
+	type Config struct {
+		Host    string        `name:"HOST"`
+		Port    int           `name:"PORT"`
+		Debug   bool          `name:"DEBUG"`
+		Name    string        `name:"APP_NAME"`
+		Timeout time.Duration `name:"TIMEOUT"`
+		Hosts   []string      `name:"ALLOWED_HOSTS"`
+		Ignored string
+	}
+
+	config := Config{
+		Host:    "localhost",
+		Port:    8080,
+		Debug:   true,
+		Name:    `My "App" #1`,
+		Timeout: 90 * time.Second,
+		Hosts:   []string{"a.com", "b.com"},
+		Ignored: "x",
+	}
+
+	filename := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(filename, []byte("OLD=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Save(filename, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "HOST=localhost\nPORT=8080\nDEBUG=true\nAPP_NAME=\"My \\\"App\\\" #1\"\nTIMEOUT=1m30s\nALLOWED_HOSTS=a.com,b.com\n"
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	if info, err := os.Stat(filename); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the existing file permissions to be kept, got %v", info.Mode())
+	}
+
+	loaded := Config{}
+	if err := LoadConfig(filename, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	config.Ignored = ""
+	if fmt.Sprint(loaded) != fmt.Sprint(config) {
+		t.Errorf("Expected round trip to load %+v, got %+v", config, loaded)
+	}
+
+	if err := Save(filename, map[string]interface{}{"B": 2, "A": "hello world"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ = os.ReadFile(filename)
+	if string(data) != "A=\"hello world\"\nB=2\n" {
+		t.Errorf("Expected sorted map entries, got:\n%s", data)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(filename))
+	if len(entries) != 1 {
+		t.Errorf("Expected temporary files to be removed, got %d entries", len(entries))
+	}
+}
+
+func TestSaveRoundTripInterpolation(t *testing.T) {
+	t.Setenv("SAVE_HOST", "expanded")
+
+	config := map[string]interface{}{
+		"TEMPLATE": "Hello ${NAME}!",
+		"URL":      "postgres://${SAVE_HOST}:5432",
+		"PLAIN":    "value",
+	}
+
+	filename := filepath.Join(t.TempDir(), ".env")
+	if err := Save(filename, config); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "PLAIN=value\nTEMPLATE='Hello ${NAME}!'\nURL='postgres://${SAVE_HOST}:5432'\n"
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+
+	loaded := map[string]interface{}{}
+	if err := LoadConfig(filename, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(loaded) != fmt.Sprint(config) {
+		t.Errorf("Expected round trip to load %v, got %v", config, loaded)
+	}
+
+	if err := Save(filename, map[string]interface{}{"BAD": "it's ${NAME}"}); err == nil {
+		t.Error("Expected error for a value with ${ and a single quote")
+	}
+}
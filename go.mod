@@ -1,6 +1,6 @@
 module github.com/abiiranathan/gora
 
-go 1.19
+go 1.20
 
 require (
 	github.com/go-playground/locales v0.14.0
@@ -11,7 +11,8 @@ require (
 	github.com/gorilla/websocket v1.5.0
 	github.com/mileusna/useragent v1.2.1
 	github.com/rs/zerolog v1.28.0
-	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e
+	golang.org/x/crypto v0.14.0
+	golang.org/x/net v0.17.0
 )
 
 require (
@@ -19,6 +20,6 @@ require (
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	github.com/stretchr/testify v1.8.1 // indirect
-	golang.org/x/sys v0.0.0-20220908164124-27713097b956 // indirect
-	golang.org/x/text v0.4.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/text v0.13.0 // indirect
 )
@@ -0,0 +1,223 @@
+package gora
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var ErrNotFormEncoded = errors.New("request body is not application/x-www-form-urlencoded or multipart/form-data")
+
+/*
+BindForm binds a form-encoded request body to v, a pointer to a struct.
+Fields are matched by their form tag, falling back to the field name.
+Supports string, bool, int, uint, float, time.Time and time.Duration fields
+and slices of the same.
+Fields tagged form:"-" are skipped.
+
+	type Login struct {
+		Username string   `form:"username"`
+		Remember bool     `form:"remember"`
+		Roles    []string `form:"roles"`
+	}
+
+Returns ErrNotFormEncoded if the Content-Type is not application/x-www-form-urlencoded
+or multipart/form-data.
+*/
+func (c *Context) BindForm(v any) error {
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		if err := c.Request.ParseForm(); err != nil {
+			return err
+		}
+	case "multipart/form-data":
+		if err := c.Request.ParseMultipartForm(MaxMultipartMemory); err != nil {
+			return err
+		}
+	default:
+		return ErrNotFormEncoded
+	}
+	return bindValues(v, c.Request.PostForm, "form", false)
+}
+
+/*
+BindQuery binds the URL query parameters to v, a pointer to a struct.
+Fields are matched by their query tag, falling back to the field name.
+Besides the types supported by BindForm, time.Time (RFC3339 or a date e.g 2024-01-31)
+and time.Duration fields are supported. Slices are populated from repeated
+or comma-separated parameters e.g ?ids=1&ids=2 or ?ids=1,2.
+
+	type Filter struct {
+		CreatedAfter time.Time `query:"created_after"`
+		IDs          []int     `query:"ids"`
+	}
+
+Conversion errors name the offending query key.
+*/
+func (c *Context) BindQuery(v any) error {
+	return bindValues(v, c.Request.URL.Query(), "query", true)
+}
+
+// Alias to c.BindForm followed by c.Validate.
+// Panics if BindForm on v fails.
+func (c *Context) MustBindForm(v any) validator.ValidationErrors {
+	if err := c.BindForm(v); err != nil {
+		panic(err)
+	}
+	return c.Validate(v)
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Sets the fields of the struct pointed to by v from values,
+// using the field's tag (or name) as the key.
+// If splitComma is true, slice values are also split on commas.
+func bindValues(v any, values url.Values, tag string, splitComma bool) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("gora: bind target must be a non-nil pointer to a struct")
+	}
+	return bindStruct(rv.Elem(), values, tag, splitComma)
+}
+
+func bindStruct(rv reflect.Value, values url.Values, tag string, splitComma bool) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fieldValue := rv.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Type != timeType {
+			if err := bindStruct(fieldValue, values, tag, splitComma); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		key := field.Tag.Get(tag)
+		if key == "-" {
+			continue
+		}
+
+		if key == "" {
+			key = field.Name
+		}
+
+		vals, ok := values[key]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+
+		if splitComma && fieldValue.Kind() == reflect.Slice {
+			vals = splitValues(vals)
+		}
+
+		if err := setField(fieldValue, vals); err != nil {
+			return fmt.Errorf("gora: invalid value for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// Sets field from vals. Slices receive all values, other kinds the first.
+func setField(field reflect.Value, vals []string) error {
+	if field.Kind() != reflect.Slice {
+		return setValue(field, vals[0])
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+	for i, val := range vals {
+		if err := setValue(slice.Index(i), val); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// Splits comma-separated values, dropping empty elements.
+func splitValues(vals []string) []string {
+	split := make([]string, 0, len(vals))
+	for _, val := range vals {
+		for _, part := range strings.Split(val, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				split = append(split, part)
+			}
+		}
+	}
+	return split
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch v.Type() {
+	case timeType:
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Parses s as an RFC3339 timestamp or a date e.g 2024-01-31.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
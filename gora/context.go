@@ -1,7 +1,9 @@
 package gora
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/xml"
@@ -9,10 +11,15 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"io/fs"
+	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,65 +37,209 @@ var (
 // Maximum memory in bytes for file uploads
 var MaxMultipartMemory int64 = 32 << 20
 
+// Maximum size in bytes of a raw request body file saved with SaveRawFile
+var MaxRawFileSize int64 = 32 << 20
+
+var ErrMultipartBody = errors.New("request body is multipart, use ParseMultipartFile instead")
+
 type Map map[string]any
 
 type Writer struct {
 	statusCode    int
 	headerWritten bool
+	discardBody   bool          // Discard writes e.g for HEAD requests served by GET routes
+	buffer        *bytes.Buffer // Captures the body while buffering. See Context.BufferResponse
 	http.ResponseWriter
 }
 
 // Implement WriteHeader to intercept the statusCode of the request for logging.
+// The status is recorded and only written to the underlying ResponseWriter on the
+// first Write or when the request completes, so headers set after calling WriteHeader
+// (e.g c.Status(201).HTML(...)) are still sent.
+// Only the first call sets the status, subsequent calls are ignored.
+// Informational 1xx statuses (e.g 103 Early Hints from a proxied upstream) other than
+// 101 Switching Protocols are written immediately and do not set the final status.
 func (w *Writer) WriteHeader(statusCode int) {
-	if w.headerWritten && statusCode != 0 {
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		if !w.headerWritten {
+			w.ResponseWriter.WriteHeader(statusCode)
+		}
 		return
 	}
 
+	if w.headerWritten || w.statusCode != 0 {
+		return
+	}
 	w.statusCode = statusCode
+}
+
+// Write the recorded status code to the underlying ResponseWriter if not yet written.
+// Defaults to 200 OK if no status was set.
+func (w *Writer) writeHeaderNow() {
+	if w.headerWritten {
+		return
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
 	w.ResponseWriter.WriteHeader(w.statusCode)
 	w.headerWritten = true
 }
 
+// Discards the recorded status and any buffered body so that another response
+// can be sent. Returns false, changing nothing, if the header was already written.
+func (w *Writer) reset() bool {
+	if w.headerWritten {
+		return false
+	}
+
+	w.statusCode = 0
+	if w.buffer != nil {
+		w.buffer.Reset()
+	}
+	return true
+}
+
+// Write data to the response.
+// Writes the header first if it has not been written.
+func (w *Writer) Write(data []byte) (int, error) {
+	if w.buffer != nil {
+		w.WriteHeader(http.StatusOK)
+		return w.buffer.Write(data)
+	}
+
+	w.writeHeaderNow()
+	if w.discardBody {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush sends any buffered data to the client.
+// Implements the http.Flusher interface. Writes the header first if it has not been written.
+// Flushing stops response buffering, so streaming handlers are sent as they write.
+// Does nothing if the underlying ResponseWriter does not support flushing.
+func (w *Writer) Flush() {
+	w.flushBuffer()
+	w.writeHeaderNow()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Returns the body captured since Context.BufferResponse was called,
+// or nil if the response is not buffered. Middleware may modify the buffer
+// (e.g compress it) before the response completes.
+func (w *Writer) Buffer() *bytes.Buffer {
+	return w.buffer
+}
+
+// Stops buffering and writes the header and any buffered body.
+func (w *Writer) flushBuffer() {
+	if w.buffer == nil {
+		return
+	}
+
+	buffer := w.buffer
+	w.buffer = nil
+	if buffer.Len() > 0 {
+		w.Write(buffer.Bytes())
+	}
+}
+
+// Returns the underlying ResponseWriter.
+// Used by http.ResponseController to reach connection features e.g read deadlines.
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack lets the caller take over the connection e.g to upgrade it to a websocket.
+// Implements the http.Hijacker interface if the underlying ResponseWriter does.
+func (w *Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http.Hijacker interface is not supported")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		// The connection now belongs to the caller, never write the header.
+		w.headerWritten = true
+		w.buffer = nil
+	}
+	return conn, rw, err
+}
+
 // Context encapsulates request/response operations.
 type Context struct {
 	Request  *http.Request     // Incoming request
 	Response *Writer           // http response writer
 	Params   map[string]string // Path parameters
 
+	// Pattern of the matched route e.g /users/{id:int}
+	route string
+
 	// Signal that request has been aborted
 	aborted bool
 
 	// Validation for structs after data binding
 	validator *Validator
 
+	// Router that dispatched the request
+	router *Router
+
 	// mutex to guard the data
 	mu sync.RWMutex
 
 	// Context data
 	data map[string]any
 
-	// Logger
+	// Logger for the request, tagged with its method, path and request id.
 	Logger zerolog.Logger
 }
 
+// Returns the pattern of the matched route e.g /users/{id:int}.
+// Returns an empty string if no route matched the request.
+func (c *Context) Route() string {
+	return c.route
+}
+
 // Returns a query parameter by key.
 func (c *Context) Query(key string) string {
 	return c.Request.URL.Query().Get(key)
 }
 
-var ErrInvalidParam = errors.New("invalid url parameter")
+// Returns all values of a repeated query parameter e.g ?tag=a&tag=b.
+// Returns nil if key is not present.
+func (c *Context) QueryArray(key string) []string {
+	return c.Request.URL.Query()[key]
+}
 
-// Get parameter as an integer. If key does not exist
-// not a valid integer, sends a 401 http response.
-func (c *Context) IntParam(key string) (int, error) {
-	if val, ok := c.Params[key]; ok {
-		valInt, err := strconv.Atoi(val)
-		if err != nil {
-			return 0, ErrInvalidParam
+// Returns the query parameters with bracketed keys under prefix,
+// keyed by the name in brackets. Only the first value of each key is used.
+// e.g ?filter[name]=x&filter[age]=30 with prefix "filter" returns
+// map[name:x age:30]. Returns an empty map if none match.
+func (c *Context) QueryMap(prefix string) map[string]string {
+	result := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if len(values) == 0 || len(key) <= len(prefix)+2 || !strings.HasPrefix(key, prefix+"[") {
+			continue
+		}
+
+		if end := strings.IndexByte(key, ']'); end == len(key)-1 {
+			result[key[len(prefix)+1:end]] = values[0]
 		}
-		return valInt, nil
 	}
-	return 0, ErrInvalidParam
+	return result
+}
+
+var ErrInvalidParam = errors.New("invalid url parameter")
+
+// Get parameter as an integer.
+// Returns ErrInvalidParam if key does not exist or is not a valid integer.
+func (c *Context) IntParam(key string) (int, error) {
+	return ParamAs[int](c, key)
 }
 
 // returns a parameter for the key. if it does not exist, returns an empty string.
@@ -96,17 +247,55 @@ func (c *Context) Param(key string) string {
 	return c.Params[key]
 }
 
-// Get parameter as an integer. If key does not exist
-// not a valid integer, IntParam panics.
+// Get parameter as an unsigned integer.
+// Returns ErrInvalidParam if key does not exist or is not a valid unsigned integer.
 func (c *Context) UintParam(key string) (uint, error) {
-	if val, ok := c.Params[key]; ok {
-		valInt, err := strconv.Atoi(val)
-		if err != nil {
-			return 0, ErrInvalidParam
-		}
-		return uint(valInt), nil
+	return ParamAs[uint](c, key)
+}
+
+// Get parameter as a UUID string in the canonical 8-4-4-4-12 hex form.
+// Returns ErrInvalidParam if key does not exist or is not a valid UUID.
+func (c *Context) UUIDParam(key string) (string, error) {
+	val, ok := c.Params[key]
+	if !ok || !uuidRegex.MatchString(val) {
+		return "", ErrInvalidParam
 	}
-	return 0, ErrInvalidParam
+	return val, nil
+}
+
+// Parse the path parameter for key into T.
+// Returns ErrInvalidParam if key does not exist or can not be parsed into T.
+//
+//	id, err := gora.ParamAs[int64](c, "id")
+func ParamAs[T int | uint | int64 | float64 | bool](c *Context, key string) (T, error) {
+	var result T
+
+	val, ok := c.Params[key]
+	if !ok {
+		return result, ErrInvalidParam
+	}
+
+	var err error
+	switch p := any(&result).(type) {
+	case *int:
+		*p, err = strconv.Atoi(val)
+	case *uint:
+		var v uint64
+		v, err = strconv.ParseUint(val, 10, 0)
+		*p = uint(v)
+	case *int64:
+		*p, err = strconv.ParseInt(val, 10, 64)
+	case *float64:
+		*p, err = strconv.ParseFloat(val, 64)
+	case *bool:
+		*p, err = strconv.ParseBool(val)
+	}
+
+	if err != nil {
+		var zero T
+		return zero, ErrInvalidParam
+	}
+	return result, nil
 }
 
 // Get parameter as an integer. If key does not exist
@@ -161,8 +350,170 @@ func (c *Context) MustGet(key string) (value any) {
 	panic("value for key " + key + " not found in the context")
 }
 
-func (c *Context) Header(key string, value string) {
+// Set a response header. Returns the context for chaining.
+func (c *Context) Header(key string, value string) *Context {
 	c.Response.Header().Set(key, value)
+	return c
+}
+
+// Set each key/value pair as a response header. Returns the context for chaining.
+//
+//	c.Headers(map[string]string{"X-A": "1", "X-B": "2"}).JSON(data)
+func (c *Context) Headers(headers map[string]string) *Context {
+	for key, value := range headers {
+		c.Response.Header().Set(key, value)
+	}
+	return c
+}
+
+/*
+CookieOptions are deployment-wide cookie attributes configured with Router.CookieDefaults.
+Domain, Path and SameSite are used for cookies that don't set them.
+Secure and HttpOnly are enabled on every cookie if set, so that a single call
+can not forget them.
+
+	r.CookieDefaults(gora.CookieOptions{
+		Domain:   "example.com",
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+*/
+type CookieOptions struct {
+	Domain   string
+	Path     string
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+}
+
+// Returns the router's cookie defaults or zero options if the context has no router.
+func (c *Context) cookieDefaults() CookieOptions {
+	if c.router == nil {
+		return CookieOptions{}
+	}
+	return c.router.cookieDefaults
+}
+
+// Add a Set-Cookie header to the response, applying the router's CookieDefaults.
+// cookie is not modified, so it can be reused as a template across requests.
+// Must be called before the response body is written.
+func (c *Context) SetCookie(template *http.Cookie) {
+	cookie := *template
+	defaults := c.cookieDefaults()
+	if cookie.Domain == "" {
+		cookie.Domain = defaults.Domain
+	}
+
+	if cookie.Path == "" {
+		cookie.Path = defaults.Path
+	}
+
+	if cookie.SameSite == 0 {
+		cookie.SameSite = defaults.SameSite
+	}
+
+	cookie.Secure = cookie.Secure || defaults.Secure
+	cookie.HttpOnly = cookie.HttpOnly || defaults.HttpOnly
+	http.SetCookie(c.Response, &cookie)
+}
+
+// Set a cookie for the whole site ("/") with SameSite=Lax,
+// unless the router's CookieDefaults configure another path or SameSite.
+// The value is URL-encoded and can be read back with c.Cookie.
+// maxAge is in seconds. A negative maxAge deletes the cookie.
+func (c *Context) SetSimpleCookie(name, value string, maxAge int, httpOnly, secure bool) {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	defaults := c.cookieDefaults()
+	if defaults.Path != "" {
+		cookie.Path = defaults.Path
+	}
+
+	if defaults.SameSite != 0 {
+		cookie.SameSite = defaults.SameSite
+	}
+	c.SetCookie(cookie)
+}
+
+// Returns the URL-decoded value of the named request cookie.
+// Returns http.ErrNoCookie if the cookie is not found.
+func (c *Context) Cookie(name string) (string, error) {
+	cookie, err := c.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(cookie.Value)
+}
+
+// Set the ETag response header. tag is quoted if it is not already
+// e.g "v1" or W/"v1" for a weak ETag.
+func (c *Context) SetETag(tag string) *Context {
+	if !strings.HasPrefix(tag, "W/") && !strings.HasPrefix(tag, `"`) {
+		tag = `"` + tag + `"`
+	}
+	c.Response.Header().Set("ETag", tag)
+	return c
+}
+
+// Reports whether the client's cached copy is still fresh, comparing the If-None-Match
+// request header to the ETag response header, or if absent, the If-Modified-Since
+// request header to the Last-Modified response header. Set the ETag (with SetETag)
+// or Last-Modified header before calling it.
+//
+//	c.SetETag(article.Version)
+//	if c.IsNotModified() {
+//		c.NotModified()
+//		return
+//	}
+func (c *Context) IsNotModified() bool {
+	header := c.Response.Header()
+
+	if inm := c.Request.Header.Get("If-None-Match"); inm != "" {
+		etag := header.Get("ETag")
+		if etag == "" {
+			return false
+		}
+
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		return false
+	}
+
+	ims, err := http.ParseTime(c.Request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(ims)
+}
+
+// Send 304 Not Modified without a body.
+// Removes headers describing the body that is not sent.
+func (c *Context) NotModified() {
+	header := c.Response.Header()
+	header.Del("Content-Type")
+	header.Del("Content-Length")
+	c.Response.WriteHeader(http.StatusNotModified)
 }
 
 // Write the status code of the response.
@@ -182,6 +533,35 @@ func (c *Context) StatusCode() int {
 	return c.Response.statusCode
 }
 
+/*
+BufferResponse captures the response body in memory instead of sending it,
+so that middleware can observe or transform the full body after the handler returns.
+The status code and headers are sent with the body when the request completes.
+Calling Flush (e.g from SSEvent or a streaming handler) sends the buffered body
+and stops buffering.
+
+	func ETag(next gora.HandlerFunc) gora.HandlerFunc {
+		return func(c *gora.Context) {
+			c.BufferResponse()
+			next(c)
+			if body := c.Response.Buffer(); body != nil {
+				c.SetETag(fmt.Sprintf("%x", sha1.Sum(body.Bytes())))
+			}
+		}
+	}
+*/
+func (c *Context) BufferResponse() {
+	if c.Response.buffer == nil && !c.Response.headerWritten {
+		c.Response.buffer = new(bytes.Buffer)
+	}
+}
+
+// Reports whether a status code or body has already been written to the response.
+// Once written, the status code can no longer be changed.
+func (c *Context) Written() bool {
+	return c.Response.headerWritten || c.Response.statusCode != 0
+}
+
 // Read the request body into buffer p.
 // Makes Context a Reader interface.
 func (c *Context) Read(p []byte) (int, error) {
@@ -191,7 +571,7 @@ func (c *Context) Read(p []byte) (int, error) {
 // Send encoded JSON response.
 // Sets conent-type header as application/json.
 func (c *Context) JSON(data any) {
-	b, err := json.Marshal(data)
+	b, err := Marshal(data)
 	if err != nil {
 		panic(err)
 	}
@@ -200,10 +580,120 @@ func (c *Context) JSON(data any) {
 	c.Response.Write(b)
 }
 
+// Send encoded JSON response with only the named fields (by json tag).
+// Nested fields are selected with dotted paths e.g "author.name".
+// Fields are applied to each element if data is a slice.
+// If fields is empty, all fields are sent.
+func (c *Context) JSONFiltered(data any, fields []string) {
+	if len(fields) == 0 {
+		c.JSON(data)
+		return
+	}
+
+	b, err := Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		panic(err)
+	}
+
+	c.JSON(filterFields(value, newFieldTree(fields)))
+}
+
+// Like JSONFiltered but reads the fields from the comma separated
+// "fields" query parameter. e.g ?fields=id,name,author.name
+func (c *Context) JSONFields(data any) {
+	var fields []string
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	c.JSONFiltered(data, fields)
+}
+
+// Tree of selected fields. A nil subtree selects the whole field.
+type fieldTree map[string]fieldTree
+
+func newFieldTree(fields []string) fieldTree {
+	tree := fieldTree{}
+	for _, field := range fields {
+		node := tree
+		parts := strings.Split(field, ".")
+		for i, part := range parts {
+			sub, exists := node[part]
+			if exists && sub == nil {
+				break // Whole field already selected
+			}
+
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+
+			if !exists {
+				sub = fieldTree{}
+				node[part] = sub
+			}
+			node = sub
+		}
+	}
+	return tree
+}
+
+func filterFields(value any, tree fieldTree) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(tree))
+		for key, sub := range tree {
+			if fieldValue, ok := v[key]; ok {
+				if sub == nil {
+					out[key] = fieldValue
+				} else {
+					out[key] = filterFields(fieldValue, sub)
+				}
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, el := range v {
+			out[i] = filterFields(el, tree)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// Send encoded XML response.
+// Sets conent-type header as application/xml.
+func (c *Context) XML(data any) {
+	b, err := xml.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	c.Response.Header().Set("Content-Type", "application/xml")
+	c.Response.Write(b)
+}
+
 // Send binary data as response.
 // Sets appropriate content-type as application/octet-stream.
 func (c *Context) Binary(data []byte) {
-	c.Response.Header().Set("Content-Type", "application/octet-stream")
+	c.Blob("application/octet-stream", data)
+}
+
+// Send data as response with the given content type e.g application/pdf.
+// Sets the Content-Length header to the length of data.
+func (c *Context) Blob(contentType string, data []byte) {
+	c.Response.Header().Set("Content-Type", contentType)
+	c.Response.Header().Set("Content-Length", strconv.Itoa(len(data)))
 	c.Response.Write(data)
 }
 
@@ -213,9 +703,62 @@ func (c *Context) String(text string) {
 	c.Response.Write([]byte(text))
 }
 
+/*
+SSEvent writes a server-sent event and flushes it to the client.
+Strings are sent as is, any other data is encoded as JSON.
+Omits the event field if event is empty so that the client receives a "message" event.
+
+Handlers usually send events until the client disconnects:
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case update := <-updates:
+			ctx.SSEvent("update", update)
+		}
+	}
+*/
+func (c *Context) SSEvent(event string, data any) {
+	if !c.Response.headerWritten {
+		header := c.Response.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+	}
+
+	var payload string
+	switch d := data.(type) {
+	case string:
+		payload = d
+	case []byte:
+		payload = string(d)
+	default:
+		b, err := Marshal(data)
+		if err != nil {
+			panic(err)
+		}
+		payload = string(b)
+	}
+
+	var buf strings.Builder
+	if event != "" {
+		buf.WriteString("event: " + event + "\n")
+	}
+
+	// Each line of multi-line data needs its own data field.
+	for _, line := range strings.Split(payload, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+
+	c.Response.Write([]byte(buf.String()))
+	c.Response.Flush()
+}
+
 // Send an HTML response as text/html.
+// The status defaults to 200 OK unless already set with c.Status.
 func (c *Context) HTML(html string) {
-	c.Response.WriteHeader(http.StatusOK)
 	c.Response.Header().Set("Content-Type", "text/html")
 	c.Response.Write([]byte(html))
 }
@@ -225,6 +768,106 @@ func (c *Context) File(filePath string) {
 	http.ServeFile(c.Response, c.Request, filePath)
 }
 
+// Send content that is not on disk (e.g an object storage download) with http.ServeContent.
+// Supports range requests and If-Modified-Since and If-None-Match conditional requests.
+// The Content-Type is detected from the extension of name or the content if not set.
+// A zero modTime omits the Last-Modified header.
+func (c *Context) ServeContent(name string, modTime time.Time, content io.ReadSeeker) {
+	http.ServeContent(c.Response, c.Request, name, modTime, content)
+}
+
+/*
+FileWithContext serves the file at filePath with http.ServeContent (supporting range requests),
+stopping once the request context is done. Use it for large files with a deadline set on the
+request context, so that serving stops at the deadline instead of running to completion.
+
+The context is checked between reads of the file: a single read that blocks
+(e.g on a stalled network mount) is not interrupted and delays the handler until it returns.
+
+If the context is done before any of the file is sent, the request is aborted with 504 Gateway Timeout.
+Responds with 404 if the file does not exist or is a directory.
+*/
+func (c *Context) FileWithContext(filePath string) {
+	ctx := c.Request.Context()
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		c.abortFileError(err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		c.abortFileError(err)
+		return
+	}
+
+	if info.IsDir() {
+		c.Abort(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+
+	content := &contextReadSeeker{ctx: ctx, rs: f}
+	http.ServeContent(c.Response, c.Request, info.Name(), info.ModTime(), content)
+
+	if content.err == nil {
+		return
+	}
+
+	c.Logger.Warn().Err(content.err).Str("file", filePath).Msg("serving file stopped")
+	if !c.Response.reset() {
+		c.AbortRequest()
+		return
+	}
+
+	// Discard the headers set by ServeContent.
+	header := c.Response.Header()
+	for _, key := range []string{"Content-Length", "Content-Range", "Content-Type", "Last-Modified", "Accept-Ranges"} {
+		header.Del(key)
+	}
+	c.Abort(http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout))
+}
+
+// Aborts with the status code matching a file system error.
+// Permission errors use Forbidden so that the router's forbidden handler applies.
+func (c *Context) abortFileError(err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		c.Abort(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+	case errors.Is(err, fs.ErrPermission):
+		c.Forbidden(http.StatusText(http.StatusForbidden))
+	default:
+		c.Abort(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
+
+// ReadSeeker that stops with the context's error once it is done.
+// The error is recorded since http.ServeContent discards copy errors.
+type contextReadSeeker struct {
+	ctx context.Context
+	rs  io.ReadSeeker
+	err error
+}
+
+func (r *contextReadSeeker) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		r.err = err
+		return 0, err
+	}
+
+	n, err := r.rs.Read(p)
+	if err != nil && r.ctx.Err() != nil {
+		r.err = r.ctx.Err()
+		return n, r.err
+	}
+	return n, err
+}
+
+func (r *contextReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return r.rs.Seek(offset, whence)
+}
+
 // Render a template/templates using template.ParseFiles using data
 // and sends the resulting output as a text/html response.
 func (c *Context) Render(status int, data any, filenames ...string) {
@@ -239,8 +882,8 @@ func (c *Context) Render(status int, data any, filenames ...string) {
 		panic(err)
 	}
 
-	c.Response.WriteHeader(status)
 	c.Response.Header().Set("Content-Type", "text/html")
+	c.Response.WriteHeader(status)
 	c.Response.Write(buf.Bytes())
 }
 
@@ -259,6 +902,23 @@ func (c *Context) Abort(status int, message string) {
 	c.aborted = true
 }
 
+/*
+Check panics with err if it is not nil, leaving the Recovery middleware to send the response.
+Recovery MUST be installed (e.g with gora.Default or r.Use(gora.Recovery)),
+otherwise the panic is only recovered by net/http, which drops the connection.
+
+An *HTTPError is sent as JSON with its status code, any other error as 500 Internal Server Error.
+
+	user, err := repo.FetchUser(id)
+	ctx.Check(err)
+	ctx.JSON(user)
+*/
+func (c *Context) Check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
 // Marks the request as aborted without sending any response.
 // All pending middleware will not run.
 func (c *Context) AbortRequest() {
@@ -275,10 +935,133 @@ func (c *Context) AbortWithError(status int, err error) {
 	c.aborted = true
 }
 
+// Abort the request and cancel all processing down the middleware chain.
+// Sends obj as a JSON response with the given status code.
+func (c *Context) AbortWithStatusJSON(status int, obj any) {
+	c.Status(status).JSON(obj)
+	c.aborted = true
+}
+
+// Abort the request and cancel all processing down the middleware chain.
+// Sends only the status code without a body.
+func (c *Context) AbortWithStatus(status int) {
+	c.Status(status)
+	c.aborted = true
+}
+
+// AbortHandler sends the response for a rejected request.
+// The message describes why the request was rejected.
+type AbortHandler func(c *Context, message string)
+
+// Abort the request with 401 Unauthorized.
+// Uses the router's unauthorized handler if set with Router.SetUnauthorizedHandler.
+func (c *Context) Unauthorized(message string) {
+	if c.router != nil && c.router.unauthorized != nil {
+		c.router.unauthorized(c, message)
+		c.aborted = true
+		return
+	}
+	c.Abort(http.StatusUnauthorized, message)
+}
+
+// Abort the request with 403 Forbidden.
+// Uses the router's forbidden handler if set with Router.SetForbiddenHandler.
+func (c *Context) Forbidden(message string) {
+	if c.router != nil && c.router.forbidden != nil {
+		c.router.forbidden(c, message)
+		c.aborted = true
+		return
+	}
+	c.Abort(http.StatusForbidden, message)
+}
+
+// Reader that stops with the context's error once it is cancelled or its deadline expires.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := cr.r.Read(p)
+	if err != nil && cr.ctx.Err() != nil {
+		return n, cr.ctx.Err()
+	}
+	return n, err
+}
+
+// Decodes the request body with decode, honoring the request context's deadline.
+// The deadline is set as the read deadline of the connection, so that a read
+// blocked on a slow-loris client fails once it passes. Without connection
+// deadline support (e.g in tests) the context is only checked between reads.
+// Returns the context's error if it is done before the body is decoded.
+func (c *Context) decodeBody(decode func(r io.Reader) error) error {
+	ctx := c.Request.Context()
+	if ctx.Done() == nil {
+		return decode(c.Request.Body)
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline {
+		http.NewResponseController(c.Response).SetReadDeadline(deadline)
+	}
+
+	err := decode(&contextReader{ctx: ctx, r: c.Request.Body})
+	if err == nil {
+		return nil
+	}
+
+	// Some decoders report any read error as io.EOF. Check the deadline first:
+	// the failed read cancels the request context and may do so before the
+	// deadline's own timer fires.
+	if hasDeadline && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
 // Bind the request body to a struct.
+// Returns the request context's error (e.g context.DeadlineExceeded)
+// if the context is done before the body is read.
 func (c *Context) BindJSON(v any) error {
-	decoder := json.NewDecoder(c.Request.Body)
-	return decoder.Decode(v)
+	return c.bindJSON(v, c.router != nil && c.router.UseNumber)
+}
+
+// Like BindJSON but always decodes JSON numbers into json.Number
+// instead of float64 when binding into interface{} values.
+// Use this to preserve the precision of large integer IDs.
+func (c *Context) BindJSONNumber(v any) error {
+	return c.bindJSON(v, true)
+}
+
+func (c *Context) bindJSON(v any, useNumber bool) error {
+	return c.decodeBody(func(r io.Reader) error {
+		if useNumber || isDefaultUnmarshal() {
+			decoder := json.NewDecoder(r)
+			if useNumber {
+				decoder.UseNumber()
+			}
+			return decoder.Decode(v)
+		}
+
+		// A custom Unmarshal needs the whole body.
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			return io.EOF
+		}
+		return Unmarshal(data, v)
+	})
 }
 
 // Validates structs, pointers to structs and slices/arrays of structs.
@@ -287,8 +1070,15 @@ func (c *Context) Validate(v any) validator.ValidationErrors {
 	return c.validator.Validate(v)
 }
 
+// Like Validate but returns an error instead of panicking
+// if v is not struct, slice, array or pointers to the same.
+func (c *Context) ValidateSafe(v any) (validator.ValidationErrors, error) {
+	return c.validator.ValidateSafe(v)
+}
+
 // Alias to c.BindJSON followed by c.Validate.
-// Panics if BindJSON on v fails.
+// Panics if BindJSON on v fails, leaving the Recovery middleware to send the response.
+// Prefer ShouldBindJSON to choose the status code for bad input yourself.
 func (c *Context) MustBindJSON(v any) validator.ValidationErrors {
 	err := c.BindJSON(v)
 
@@ -302,23 +1092,140 @@ func (c *Context) MustBindJSON(v any) validator.ValidationErrors {
 	return c.validator.Validate(v)
 }
 
+// Bind the request body JSON to v and validate it.
+// Unlike MustBindJSON, ShouldBindJSON does not panic.
+// Returns ErrEmptyRequestBody if the body is empty, the decode error if decoding fails
+// and validator.ValidationErrors if validation fails.
+//
+//	if err := ctx.ShouldBindJSON(&user); err != nil {
+//		ctx.AbortWithError(http.StatusBadRequest, err)
+//		return
+//	}
+func (c *Context) ShouldBindJSON(v any) error {
+	if err := c.BindJSON(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrEmptyRequestBody
+		}
+		return err
+	}
+
+	errs, err := c.ValidateSafe(v)
+	if err != nil {
+		return err
+	}
+
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+/*
+Patch applies a partial JSON update to target, an already populated struct pointer
+e.g loaded from the database, then validates the result.
+
+Only fields present in the request body are overwritten since the body is decoded
+over the existing value. Absent fields keep their current values, while fields sent
+with a zero value (e.g "name": "" or "age": 0) are set to the zero value.
+A JSON null leaves non-pointer fields unchanged and sets pointer fields to nil.
+Nested structs and maps are merged, slices are replaced.
+
+	user, _ := repo.FetchUser(id)
+	if err := ctx.Patch(&user); err != nil {
+		ctx.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+Returns the same errors as ShouldBindJSON.
+*/
+func (c *Context) Patch(target any) error {
+	return c.ShouldBindJSON(target)
+}
+
+// Bind the request body XML to v.
+// Like BindJSON, honors the request context's deadline.
 func (c *Context) BindXML(v any) error {
-	decoder := xml.NewDecoder(c.Request.Body)
-	return decoder.Decode(v)
+	return c.decodeBody(func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(v)
+	})
 }
 
 // Alias to c.BindXML followed by c.Validate.
 // Panics if BindXML on v fails.
 // v should be a pointer to struct, slice or array.
 func (c *Context) MustBindXML(v any) validator.ValidationErrors {
-	decoder := xml.NewDecoder(c.Request.Body)
-	err := decoder.Decode(v)
+	err := c.BindXML(v)
 	if err != nil {
 		panic(err)
 	}
 	return c.Validate(v)
 }
 
+// Bind the request body XML to v and validate it.
+// Unlike MustBindXML, ShouldBindXML does not panic.
+// Returns ErrEmptyRequestBody if the body is empty, the decode error if decoding fails
+// and validator.ValidationErrors if validation fails.
+// v should be a pointer to struct, slice or array.
+func (c *Context) ShouldBindXML(v any) error {
+	if err := c.BindXML(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrEmptyRequestBody
+		}
+		return err
+	}
+
+	errs, err := c.ValidateSafe(v)
+	if err != nil {
+		return err
+	}
+
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// Reports whether the client prefers an XML response.
+// Checks the Accept header, falling back to the request Content-Type
+// if Accept is missing or accepts anything.
+func (c *Context) wantsXML() bool {
+	isXML := func(mediaType string) bool {
+		return strings.Contains(mediaType, "application/xml") || strings.Contains(mediaType, "text/xml")
+	}
+
+	accept := c.Request.Header.Get("Accept")
+	if accept != "" && accept != "*/*" {
+		return isXML(accept) && !strings.Contains(accept, "application/json")
+	}
+	return isXML(c.Request.Header.Get("Content-Type"))
+}
+
+// XML representation of translated validation errors.
+type xmlValidationErrors struct {
+	XMLName xml.Name             `xml:"errors"`
+	Errors  []xmlValidationError `xml:"error"`
+}
+
+type xmlValidationError struct {
+	Field   string `xml:"field,attr"`
+	Message string `xml:",chardata"`
+}
+
+// Convert translated validation errors to their XML representation sorted by field.
+func toXMLValidationErrors(errMap map[string]string) xmlValidationErrors {
+	fields := make([]string, 0, len(errMap))
+	for field := range errMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	out := xmlValidationErrors{Errors: make([]xmlValidationError, 0, len(fields))}
+	for _, field := range fields {
+		out.Errors = append(out.Errors, xmlValidationError{Field: field, Message: errMap[field]})
+	}
+	return out
+}
+
 /*
 This function returns two maps: one for the form values, and one for the files.
 The files map is a map of string slices of *multipart.FileHeader values,
@@ -343,6 +1250,64 @@ func (c *Context) ParseMultipartForm() (map[string][]string, map[string][]*multi
 	return formValues, formFiles, nil
 }
 
+// Limits on the files uploaded in a multipart form, checked by ValidateMultipart.
+// A zero value means no limit.
+type MultipartLimits struct {
+	MaxFiles      int   // Maximum number of files across all fields
+	MaxTotalBytes int64 // Maximum combined size of all files in bytes
+	MaxFileBytes  int64 // Maximum size of each file in bytes
+}
+
+// Returned (wrapped) by ValidateMultipart if the upload exceeds any limit.
+var ErrMultipartLimit = errors.New("multipart upload limit exceeded")
+
+/*
+ValidateMultipart parses the multipart form and checks the uploaded files against limits
+before any of them are saved e.g with SaveMultipartFiles.
+Returns an error wrapping ErrMultipartLimit that lists every limit exceeded.
+
+	err := ctx.ValidateMultipart(gora.MultipartLimits{MaxFiles: 5, MaxTotalBytes: 20 << 20})
+	if errors.Is(err, gora.ErrMultipartLimit) {
+		ctx.AbortWithError(http.StatusRequestEntityTooLarge, err)
+		return
+	}
+*/
+func (c *Context) ValidateMultipart(limits MultipartLimits) error {
+	if err := c.Request.ParseMultipartForm(MaxMultipartMemory); err != nil {
+		return err
+	}
+
+	var count int
+	var total int64
+	var violations []string
+
+	for _, files := range c.Request.MultipartForm.File {
+		for _, file := range files {
+			count++
+			total += file.Size
+
+			if limits.MaxFileBytes > 0 && file.Size > limits.MaxFileBytes {
+				violations = append(violations, fmt.Sprintf("file %q is %d bytes, max %d bytes per file",
+					file.Filename, file.Size, limits.MaxFileBytes))
+			}
+		}
+	}
+
+	if limits.MaxFiles > 0 && count > limits.MaxFiles {
+		violations = append(violations, fmt.Sprintf("%d files uploaded, max %d files", count, limits.MaxFiles))
+	}
+
+	if limits.MaxTotalBytes > 0 && total > limits.MaxTotalBytes {
+		violations = append(violations, fmt.Sprintf("%d bytes uploaded, max %d bytes in total", total, limits.MaxTotalBytes))
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("%w: %s", ErrMultipartLimit, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
 // Save multiple multipart files to disk.
 // Returns filenames of the saved files and an error if any of the os/io operations fail.
 func (c *Context) SaveMultipartFiles(formFiles map[string][]*multipart.FileHeader,
@@ -385,9 +1350,66 @@ func (c *Context) ParseMultipartFile(fieldName string) (*multipart.FileHeader, e
 	return files[0], nil
 }
 
+// Returns the first value of the form field key from a urlencoded or multipart body,
+// falling back to the URL query. Returns an empty string if key is not present.
+// Multipart forms are parsed with MaxMultipartMemory.
+func (c *Context) FormValue(key string) string {
+	c.parseForm()
+	return c.Request.FormValue(key)
+}
+
+// Returns the first file for the multipart form field key.
+// Returns http.ErrMissingFile if the field has no file.
+func (c *Context) FormFile(key string) (*multipart.FileHeader, error) {
+	if err := c.parseForm(); err != nil {
+		return nil, err
+	}
+
+	if c.Request.MultipartForm != nil {
+		if files := c.Request.MultipartForm.File[key]; len(files) > 0 {
+			return files[0], nil
+		}
+	}
+	return nil, http.ErrMissingFile
+}
+
+// Parses the request body as a multipart or urlencoded form.
+func (c *Context) parseForm() error {
+	err := c.Request.ParseMultipartForm(MaxMultipartMemory)
+	if err == http.ErrNotMultipart {
+		return nil
+	}
+	return err
+}
+
 // Save the multipart file to disk using a random name into destDir directory.
 // Returns the path to the destination filename and error if any.
 func (c *Context) SaveMultipartFile(file *multipart.FileHeader, destDir string) (string, error) {
+	return c.SaveMultipartFileProgress(file, destDir, nil)
+}
+
+// Reader that reports the number of bytes read so far to onProgress.
+type progressReader struct {
+	r          io.Reader
+	written    int64
+	total      int64
+	onProgress func(written, total int64)
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.written += int64(n)
+		pr.onProgress(pr.written, pr.total)
+	}
+	return n, err
+}
+
+// Like SaveMultipartFile but calls onProgress as chunks of the file are written to disk.
+// written is the number of bytes written so far and total is the file size (file.Size).
+// onProgress is called from the handler's goroutine and should return quickly.
+func (c *Context) SaveMultipartFileProgress(file *multipart.FileHeader, destDir string,
+	onProgress func(written, total int64)) (string, error) {
 	src, err := file.Open()
 	if err != nil {
 		return "", err
@@ -403,10 +1425,162 @@ func (c *Context) SaveMultipartFile(file *multipart.FileHeader, destDir string)
 	}
 	defer dst.Close()
 
-	if _, err := io.Copy(dst, src); err != nil {
+	var reader io.Reader = src
+	if onProgress != nil {
+		reader = &progressReader{r: src, total: file.Size, onProgress: onProgress}
+	}
+
+	if _, err := io.Copy(dst, reader); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// Returns the scheme of the request, "https" or "http".
+// A request is https if it was received over TLS or
+// the X-Forwarded-Proto header set by a trusted proxy is https.
+// See Router.SetTrustedProxies.
+func (c *Context) Scheme() string {
+	if c.Request.TLS != nil {
+		return "https"
+	}
+
+	if !c.fromTrustedProxy(c.RemoteAddr()) {
+		return "http"
+	}
+
+	proto := c.Request.Header.Get("X-Forwarded-Proto")
+	if i := strings.Index(proto, ","); i != -1 {
+		proto = proto[:i]
+	}
+
+	if strings.EqualFold(strings.TrimSpace(proto), "https") {
+		return "https"
+	}
+	return "http"
+}
+
+// Returns the absolute URL of the request: scheme, host, path and query.
+// Useful for building absolute links in emails and redirects.
+func (c *Context) FullURL() string {
+	return c.Scheme() + "://" + c.Request.Host + c.Request.URL.RequestURI()
+}
+
+// Returns the IP address of the immediate client without the port.
+func (c *Context) RemoteAddr() string {
+	host, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return host
+}
+
+/*
+ClientIP returns the IP address of the client that sent the request.
+If the request comes from a trusted proxy (see Router.SetTrustedProxies),
+the X-Forwarded-For header is read from right to left, skipping trusted proxies,
+and the first untrusted address is returned. X-Real-IP is used if
+X-Forwarded-For is not set. Otherwise, returns RemoteAddr.
+*/
+func (c *Context) ClientIP() string {
+	remoteAddr := c.RemoteAddr()
+	if !c.fromTrustedProxy(remoteAddr) {
+		return remoteAddr
+	}
+
+	if forwarded := c.Request.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if i == 0 || !c.fromTrustedProxy(hop) {
+				return hop
+			}
+		}
+	}
+
+	if realIP := strings.TrimSpace(c.Request.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+	return remoteAddr
+}
+
+// Reports whether forwarded headers sent by addr are honored.
+// Without a router there are no trusted proxies.
+func (c *Context) fromTrustedProxy(addr string) bool {
+	if c.router == nil {
+		return false
+	}
+	return c.router.isTrustedProxy(net.ParseIP(addr))
+}
+
+// ReadCloser that reads from a buffered reader and closes the underlying body.
+type rawFileReader struct {
+	io.Reader
+	io.Closer
+}
+
+// Returns a reader for a file uploaded as the raw request body
+// (e.g Content-Type: image/jpeg) rather than as multipart form data,
+// and its content type. The content type is taken from the Content-Type header
+// or detected from the first 512 bytes if the header is missing or generic.
+// Reading more than maxSize bytes returns an error.
+// Returns ErrMultipartBody for multipart requests.
+// The caller should close the returned reader.
+func (c *Context) RawFile(maxSize int64) (io.ReadCloser, string, error) {
+	contentType := c.Request.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/") {
+		return nil, "", ErrMultipartBody
+	}
+
+	body := http.MaxBytesReader(c.Response, c.Request.Body, maxSize)
+	reader := bufio.NewReaderSize(body, 512)
+
+	if contentType == "" || contentType == "application/octet-stream" {
+		head, err := reader.Peek(512)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			body.Close()
+			return nil, "", err
+		}
+
+		if len(head) == 0 {
+			body.Close()
+			return nil, "", ErrEmptyRequestBody
+		}
+		contentType = http.DetectContentType(head)
+	}
+	return rawFileReader{Reader: reader, Closer: body}, contentType, nil
+}
+
+// Save a file uploaded as the raw request body to disk using a random name into destDir.
+// The file extension is derived from the content type.
+// The body may be at most MaxRawFileSize bytes.
+// Returns the name of the saved file and an error if any.
+func (c *Context) SaveRawFile(destDir string) (string, error) {
+	src, contentType, err := c.RawFile(MaxRawFileSize)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	var ext string
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+
+	filename := fmt.Sprintf("%d-%s%s", time.Now().UnixNano(), randString(10), ext)
+	dst, err := os.Create(filepath.Join(destDir, filename))
+	if err != nil {
 		return "", err
 	}
+	defer dst.Close()
 
+	if _, err := io.Copy(dst, src); err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
 	return filename, nil
 }
 
@@ -420,3 +1594,78 @@ func (c *Context) BearerToken() string {
 	}
 	return strings.TrimSpace(parts[1])
 }
+
+/*
+APIVersion returns the API version requested by the client or an empty string.
+The version is read from a vendor media type in the Accept header
+e.g application/vnd.myapi.v2+json or application/vnd.myapi+json; version=2,
+falling back to the X-API-Version header. A leading "v" is removed so that
+both examples above return "2".
+*/
+func (c *Context) APIVersion() string {
+	for _, accept := range strings.Split(c.Request.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil || !strings.HasPrefix(mediaType, "application/vnd.") {
+			continue
+		}
+
+		if version := params["version"]; version != "" {
+			return trimVersion(version)
+		}
+
+		// application/vnd.myapi.v2+json => [myapi v2]
+		vendor := strings.TrimPrefix(mediaType, "application/vnd.")
+		if i := strings.IndexByte(vendor, '+'); i != -1 {
+			vendor = vendor[:i]
+		}
+
+		parts := strings.Split(vendor, ".")
+		for i := len(parts) - 1; i > 0; i-- {
+			if isVersion(parts[i]) {
+				return trimVersion(parts[i])
+			}
+		}
+	}
+	return trimVersion(c.Request.Header.Get("X-API-Version"))
+}
+
+// Reports whether s is of the form v1, v2, v2.1...
+func isVersion(s string) bool {
+	if len(s) < 2 || (s[0] != 'v' && s[0] != 'V') {
+		return false
+	}
+	return s[1] >= '0' && s[1] <= '9'
+}
+
+func trimVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if isVersion(version) {
+		return version[1:]
+	}
+	return version
+}
+
+/*
+NegotiateVersion sends the JSON response built by the builder registered for
+the client's APIVersion. The builder for the empty key "" is used when the client
+does not request a version or requests one that is not registered.
+Responds with 406 Not Acceptable if no builder matches.
+
+	ctx.NegotiateVersion(map[string]func() any{
+		"1": func() any { return userV1 },
+		"2": func() any { return userV2 },
+		"":  func() any { return userV2 },
+	})
+*/
+func (c *Context) NegotiateVersion(builders map[string]func() any) {
+	build, ok := builders[c.APIVersion()]
+	if !ok {
+		build, ok = builders[""]
+	}
+
+	if !ok {
+		c.Abort(http.StatusNotAcceptable, "unsupported API version")
+		return
+	}
+	c.JSON(build())
+}
@@ -0,0 +1,157 @@
+package gora
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Method name prefixes recognized by Router.Controller and their HTTP methods.
+var controllerVerbs = []struct {
+	prefix string
+	method string
+}{
+	{"Get", http.MethodGet},
+	{"Post", http.MethodPost},
+	{"Put", http.MethodPut},
+	{"Patch", http.MethodPatch},
+	{"Delete", http.MethodDelete},
+	{"Options", http.MethodOptions},
+	{"Head", http.MethodHead},
+}
+
+/*
+Controller registers the handlers of ctrl, a struct or pointer to a struct,
+as routes under prefix.
+
+Methods with the signature func(*Context) are registered by name,
+with the receiver bound. The name is an HTTP verb (Get, Post, Put, Patch,
+Delete, Options or Head) followed by the path in CamelCase, one segment per word.
+Words after By become a path parameter.
+
+	GetUsers        GET    /users
+	PostUsers       POST   /users
+	GetUsersByID    GET    /users/{id}
+	DeleteUserPosts DELETE /user/posts
+	Get             GET    /
+
+For typed parameters or paths that can't be expressed as a method name,
+use exported fields of type HandlerFunc (or func(*Context)) tagged with route:
+
+	type UserController struct {
+		Show gora.HandlerFunc `route:"GET /users/{id:int}"`
+	}
+
+	r.Controller("/api", &UserController{Show: showUser})
+
+Methods with other signatures and untagged fields are ignored.
+Panics if ctrl is not a struct or a route tag is invalid.
+*/
+func (r *Router) Controller(prefix string, ctrl any, middleware ...MiddlewareFunc) {
+	rv := reflect.ValueOf(ctrl)
+	rt := rv.Type()
+
+	structValue := rv
+	if structValue.Kind() == reflect.Ptr {
+		structValue = structValue.Elem()
+	}
+	assert(structValue.Kind() == reflect.Struct, "controller must be a struct or a pointer to a struct")
+
+	for i := 0; i < rt.NumMethod(); i++ {
+		name := rt.Method(i).Name
+		handler, ok := rv.Method(i).Interface().(func(*Context))
+		if !ok {
+			continue
+		}
+
+		method, path, ok := controllerRoute(name)
+		if !ok {
+			continue
+		}
+		r.addRoute(joinPaths(prefix, path), method, handler, middleware...)
+	}
+
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag, ok := field.Tag.Lookup("route")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		var handler HandlerFunc
+		switch h := structValue.Field(i).Interface().(type) {
+		case HandlerFunc:
+			handler = h
+		case func(*Context):
+			handler = h
+		default:
+			panic(fmt.Sprintf("gora: route field %s must be a HandlerFunc", field.Name))
+		}
+
+		method, path, found := strings.Cut(strings.TrimSpace(tag), " ")
+		if !found || handler == nil {
+			panic(fmt.Sprintf("gora: invalid route tag %q on field %s", tag, field.Name))
+		}
+		r.addRoute(joinPaths(prefix, strings.TrimSpace(path)), strings.ToUpper(method), handler, middleware...)
+	}
+}
+
+// Derives the HTTP method and path from a controller method name.
+// e.g GetUsersByID -> GET /users/{id}
+func controllerRoute(name string) (method, path string, ok bool) {
+	for _, verb := range controllerVerbs {
+		rest := strings.TrimPrefix(name, verb.prefix)
+		if rest == name || (rest != "" && !unicode.IsUpper(rune(rest[0]))) {
+			continue
+		}
+
+		var segments, params []string
+		inParam := false
+		for _, word := range splitCamelCase(rest) {
+			if word == "By" && !inParam {
+				inParam = true
+				continue
+			}
+
+			if inParam {
+				params = append(params, strings.ToLower(word))
+			} else {
+				segments = append(segments, strings.ToLower(word))
+			}
+		}
+
+		if inParam && len(params) == 0 {
+			return "", "", false
+		}
+
+		if len(params) > 0 {
+			segments = append(segments, "{"+strings.Join(params, "_")+"}")
+		}
+		return verb.method, "/" + strings.Join(segments, "/"), true
+	}
+	return "", "", false
+}
+
+// Splits a CamelCase identifier into words, keeping acronyms together.
+// e.g UsersByID -> [Users By ID], HTMLPage -> [HTML Page]
+func splitCamelCase(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsUpper(runes[i]) && (prevLower || (unicode.IsUpper(runes[i-1]) && nextLower)) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,45 @@
+package gora
+
+import "net/http"
+
+/*
+HTTPError is an error with the HTTP status code to respond with.
+The Recovery middleware renders a panicking *HTTPError as a JSON response
+with its status code e.g {"error": "user not found"}.
+
+	user, err := repo.FetchUser(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = gora.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+	ctx.Check(err)
+*/
+type HTTPError struct {
+	Code    int    // HTTP status code
+	Message string // Message sent to the client
+	Err     error  // Optional underlying error, not sent to the client
+}
+
+// Returns a new HTTPError. If message is empty, the status text of code is used.
+func NewHTTPError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Sets the underlying error and returns e.
+func (e *HTTPError) Wrap(err error) *HTTPError {
+	e.Err = err
+	return e
+}
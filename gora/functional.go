@@ -1,5 +1,7 @@
 package gora
 
+import "fmt"
+
 // Loop through any slice or array.
 func ForEach[T any](arr []T, f func(val T)) {
 	for _, el := range arr {
@@ -16,3 +18,93 @@ func MapSlice[T any, V any](arr []T, f func(val T) V) []V {
 	}
 	return result
 }
+
+// Returns a new slice with the elements of arr for which pred returns true.
+func Filter[T any](arr []T, pred func(val T) bool) []T {
+	result := make([]T, 0, len(arr))
+	for _, el := range arr {
+		if pred(el) {
+			result = append(result, el)
+		}
+	}
+	return result
+}
+
+// Reduce arr to a single value, calling f with the accumulator and each element.
+// The first param is the slice element type, the second the accumulator type.
+// Returns init if arr is empty.
+func Reduce[T any, A any](arr []T, init A, f func(acc A, val T) A) A {
+	acc := init
+	for _, el := range arr {
+		acc = f(acc, el)
+	}
+	return acc
+}
+
+// Returns the first element of arr for which pred returns true.
+// ok is false if no element matches.
+func Find[T any](arr []T, pred func(val T) bool) (result T, ok bool) {
+	for _, el := range arr {
+		if pred(el) {
+			return el, true
+		}
+	}
+	return result, false
+}
+
+// Groups the elements of arr by the key returned by key.
+// Elements keep their relative order within each group.
+func GroupBy[T any, K comparable](arr []T, key func(val T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, el := range arr {
+		k := key(el)
+		groups[k] = append(groups[k], el)
+	}
+	return groups
+}
+
+// Splits arr into slices of length size. The last chunk is shorter
+// if len(arr) is not a multiple of size.
+// Chunks share the backing array of arr. Panics if size <= 0.
+func Chunk[T any](arr []T, size int) [][]T {
+	assert(size > 0, "chunk size must be greater than 0")
+
+	chunks := make([][]T, 0, (len(arr)+size-1)/size)
+	for start := 0; start < len(arr); start += size {
+		end := start + size
+		if end > len(arr) {
+			end = len(arr)
+		}
+		chunks = append(chunks, arr[start:end:end])
+	}
+	return chunks
+}
+
+// Like MapSlice but f may fail. Stops at the first error and returns it
+// wrapped with the index of the failing element, e.g "index 2: invalid syntax".
+func MapSliceErr[T any, V any](arr []T, f func(val T) (V, error)) ([]V, error) {
+	result := make([]V, len(arr))
+	for i, el := range arr {
+		v, err := f(el)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+// Like Reduce but f may fail. Stops at the first error and returns the zero value
+// of the accumulator and the error wrapped with the index of the failing element.
+func TryReduce[T any, A any](arr []T, init A, f func(acc A, val T) (A, error)) (A, error) {
+	acc := init
+	for i, el := range arr {
+		next, err := f(acc, el)
+		if err != nil {
+			var zero A
+			return zero, fmt.Errorf("index %d: %w", i, err)
+		}
+		acc = next
+	}
+	return acc, nil
+}
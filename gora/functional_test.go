@@ -0,0 +1,225 @@
+package gora
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	isEven := func(n int) bool { return n%2 == 0 }
+
+	tests := []struct {
+		name     string
+		arr      []int
+		expected []int
+	}{
+		{"empty", []int{}, []int{}},
+		{"nil", nil, []int{}},
+		{"no match", []int{1, 3, 5}, []int{}},
+		{"some match", []int{1, 2, 3, 4}, []int{2, 4}},
+		{"all match", []int{2, 4}, []int{2, 4}},
+	}
+
+	for _, test := range tests {
+		if result := Filter(test.arr, isEven); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestReduce(t *testing.T) {
+	t.Parallel()
+
+	sum := func(acc int, n int) int { return acc + n }
+
+	tests := []struct {
+		name     string
+		arr      []int
+		init     int
+		expected int
+	}{
+		{"empty", []int{}, 10, 10},
+		{"nil", nil, 0, 0},
+		{"sum", []int{1, 2, 3}, 0, 6},
+		{"sum with init", []int{1, 2, 3}, 10, 16},
+	}
+
+	for _, test := range tests {
+		if result := Reduce(test.arr, test.init, sum); result != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, result)
+		}
+	}
+
+	// The accumulator type may differ from the element type.
+	lengths := Reduce([]string{"a", "bb", "ccc"}, map[string]int{}, func(acc map[string]int, s string) map[string]int {
+		acc[s] = len(s)
+		return acc
+	})
+
+	if !reflect.DeepEqual(lengths, map[string]int{"a": 1, "bb": 2, "ccc": 3}) {
+		t.Errorf("unexpected lengths: %v", lengths)
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	greaterThan2 := func(n int) bool { return n > 2 }
+
+	tests := []struct {
+		name     string
+		arr      []int
+		expected int
+		ok       bool
+	}{
+		{"empty", []int{}, 0, false},
+		{"nil", nil, 0, false},
+		{"no match", []int{1, 2}, 0, false},
+		{"first match", []int{1, 3, 4}, 3, true},
+	}
+
+	for _, test := range tests {
+		result, ok := Find(test.arr, greaterThan2)
+		if result != test.expected || ok != test.ok {
+			t.Errorf("%s: expected %d %v, got %d %v", test.name, test.expected, test.ok, result, ok)
+		}
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	t.Parallel()
+
+	type order struct {
+		customer string
+		amount   int
+	}
+
+	orders := []order{{"alice", 1}, {"bob", 2}, {"alice", 3}}
+	groups := GroupBy(orders, func(o order) string { return o.customer })
+
+	expected := map[string][]order{
+		"alice": {{"alice", 1}, {"alice", 3}},
+		"bob":   {{"bob", 2}},
+	}
+
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("expected %v, got %v", expected, groups)
+	}
+
+	if groups := GroupBy([]int{}, func(n int) int { return n }); len(groups) != 0 {
+		t.Errorf("expected no groups for empty slice, got %v", groups)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		arr      []int
+		size     int
+		expected [][]int
+	}{
+		{"empty", []int{}, 2, [][]int{}},
+		{"exact", []int{1, 2, 3, 4}, 2, [][]int{{1, 2}, {3, 4}}},
+		{"short last chunk", []int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{"size larger than slice", []int{1, 2}, 5, [][]int{{1, 2}}},
+		{"size 1", []int{1, 2}, 1, [][]int{{1}, {2}}},
+	}
+
+	for _, test := range tests {
+		if result := Chunk(test.arr, test.size); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+
+	// Appending to a chunk must not overwrite the next chunk.
+	arr := []int{1, 2, 3, 4}
+	chunks := Chunk(arr, 2)
+	_ = append(chunks[0], 9)
+	if arr[2] != 3 {
+		t.Errorf("append to chunk modified the next chunk: %v", arr)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Chunk to panic for size 0")
+		}
+	}()
+	Chunk(arr, 0)
+}
+
+func TestMapSliceErr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		arr      []string
+		expected []int
+		errIndex string
+	}{
+		{"empty", []string{}, []int{}, ""},
+		{"success", []string{"1", "2", "3"}, []int{1, 2, 3}, ""},
+		{"first error", []string{"1", "x", "y"}, nil, "index 1: "},
+	}
+
+	for _, test := range tests {
+		result, err := MapSliceErr(test.arr, strconv.Atoi)
+		if test.errIndex == "" {
+			if err != nil || !reflect.DeepEqual(result, test.expected) {
+				t.Errorf("%s: expected %v, got %v %v", test.name, test.expected, result, err)
+			}
+			continue
+		}
+
+		if result != nil {
+			t.Errorf("%s: expected nil result on error, got %v", test.name, result)
+		}
+
+		if !errors.Is(err, strconv.ErrSyntax) || !strings.HasPrefix(err.Error(), test.errIndex) {
+			t.Errorf("%s: expected wrapped syntax error at %q, got %v", test.name, test.errIndex, err)
+		}
+	}
+}
+
+func TestTryReduce(t *testing.T) {
+	t.Parallel()
+
+	errNegative := errors.New("negative number")
+	sum := func(acc int, n int) (int, error) {
+		if n < 0 {
+			return acc, errNegative
+		}
+		return acc + n, nil
+	}
+
+	tests := []struct {
+		name     string
+		arr      []int
+		expected int
+		err      string
+	}{
+		{"empty", []int{}, 10, ""},
+		{"success", []int{1, 2, 3}, 16, ""},
+		{"first error", []int{1, -2, -3}, 0, "index 1: negative number"},
+	}
+
+	for _, test := range tests {
+		result, err := TryReduce(test.arr, 10, sum)
+		if test.err == "" {
+			if err != nil || result != test.expected {
+				t.Errorf("%s: expected %d, got %d %v", test.name, test.expected, result, err)
+			}
+			continue
+		}
+
+		if result != test.expected || !errors.Is(err, errNegative) || err.Error() != test.err {
+			t.Errorf("%s: expected %d %q, got %d %v", test.name, test.expected, test.err, result, err)
+		}
+	}
+}
@@ -9,17 +9,25 @@ this library is a convenient and flexible tool for routing requests in Go.
 package gora
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/fs"
+	"mime"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -30,10 +38,51 @@ var StrictSlash bool = false
 // Validation tag, default "validate"
 var ValidationTag string = "validate"
 
+// Request header read for the request id attached to Context.Logger.
+var RequestIDHeader = "X-Request-ID"
+
 // Debug /or production, defaults to false.
 // If in production, turns off ConsoleWriter and writes to the io.Writer provide to the router.
 var ModeProduction bool
 
+/*
+JSON implementation used to encode responses (e.g Context.JSON) and
+decode request bodies (e.g Context.BindJSON). Defaults to github.com/goccy/go-json.
+Swap them to use encoding/json or another library:
+
+	func init() {
+		gora.Marshal = json.Marshal
+		gora.Unmarshal = json.Unmarshal
+	}
+
+Set them once at init, before serving any requests, since they are read without synchronization.
+With the default Unmarshal, request bodies are decoded as a stream and data after
+the first JSON value is ignored. A custom Unmarshal receives the whole body,
+which is read into memory first. Binding with json.Number (Router.UseNumber and
+Context.BindJSONNumber) always streams with goccy/go-json.
+*/
+var (
+	Marshal   func(v any) ([]byte, error)    = json.Marshal
+	Unmarshal func(data []byte, v any) error = json.Unmarshal
+)
+
+// Reports whether Unmarshal has not been swapped, so bodies can be streamed.
+func isDefaultUnmarshal() bool {
+	return reflect.ValueOf(Unmarshal).Pointer() == reflect.ValueOf(json.Unmarshal).Pointer()
+}
+
+// Default maximum length of the request path. See Router.MaxPathLength.
+const DefaultMaxPathLength = 2048
+
+// Output format of a logger created with NewLogger.
+type LogFormat int
+
+const (
+	LogConsole        LogFormat = iota // Human-friendly colorized output with zerolog.ConsoleWriter
+	LogJSON                            // Structured JSON output
+	LogConsoleNoColor                  // Human-friendly output without ANSI color codes
+)
+
 // Panic with text if statement is false
 func assert(statement bool, text string) {
 	if !statement {
@@ -43,20 +92,67 @@ func assert(statement bool, text string) {
 
 // Base Router implements the http.Handler interface.
 type Router struct {
-	routes     []route          // Stores all registered routes
-	middleware []MiddlewareFunc // Stores all global middleware
+	routes     map[string][]route // Registered routes keyed by HTTP method
+	methods    []string           // Methods in the order their first route was registered
+	middleware []MiddlewareFunc   // Stores all global middleware
+	pre        []MiddlewareFunc   // Middleware that runs before route matching
 
 	// Called if no path matches the request path.
 	// Useful for handling SPA frontend applications
 	notFound HandlerFunc
 
+	// Hooks that send 401 and 403 responses for built-in middleware.
+	unauthorized AbortHandler
+	forbidden    AbortHandler
+
+	// Group-scoped fallbacks, sorted by the most specific (longest) prefix first.
+	groupNotFound []groupFallback
+
+	// Base attributes of cookies set with Context.SetCookie and SetSimpleCookie.
+	cookieDefaults CookieOptions
+
+	// Proxies whose forwarded headers are honored, see SetTrustedProxies.
+	trustedProxies []*net.IPNet
+
 	// Request logger
 	Logger zerolog.Logger
+
+	// Maximum length of the request path. Longer paths are rejected
+	// with 414 URI Too Long before any route matching.
+	// Defaults to DefaultMaxPathLength if zero. Set to a negative value to disable.
+	MaxPathLength int
+
+	// Decode JSON numbers in request bodies into json.Number instead of float64
+	// when binding into interface{} values, preserving the precision of large integers.
+	UseNumber bool
+
+	// Configures the servers started by Run and RunBoth e.g to enable h2c.
+	ServerConfig ServerConfig
+
+	// Validator shared by all requests. Initialized lazily by Validator().
+	validator     *Validator
+	validatorOnce sync.Once
+
+	// Validators for other tag names, see ValidatorFor.
+	validators   map[string]*Validator
+	validatorsMu sync.Mutex
+}
+
+// A handler called for unmatched request paths under a group prefix.
+type groupFallback struct {
+	prefix  string
+	handler HandlerFunc
+}
+
+// Reports whether path is the fallback prefix or below it.
+func (f groupFallback) matches(path string) bool {
+	return path == f.prefix || strings.HasPrefix(path, f.prefix+"/")
 }
 
 // A single route. Stores url patterns, method and their corresponding handlers and middleware.
 type route struct {
 	pattern    *regexp.Regexp
+	path       string // Pattern the route was registered with e.g /users/{id:int}
 	handler    func(c *Context)
 	method     string
 	middleware []MiddlewareFunc
@@ -89,16 +185,10 @@ type StaticEmbed struct {
 // out is where to the logger should write. Defaults to os.Stderr
 func Default(out ...io.Writer) *Router {
 	if len(out) == 0 {
-		if len(out) == 0 {
-			out = append(out, os.Stderr)
-		}
-	}
-
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: out[0]})
-	if ModeProduction {
-		log.Logger = log.Output(zerolog.Logger{}.Output(out[0]))
+		out = append(out, os.Stderr)
 	}
 
+	log.Logger = defaultLogger(out[0])
 	r := &Router{Logger: log.Logger}
 	r.Use(Recovery, Logger)
 	return r
@@ -113,12 +203,68 @@ func New(out ...io.Writer) *Router {
 		out = append(out, os.Stderr)
 	}
 
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: out[0]})
+	log.Logger = defaultLogger(out[0])
+	return &Router{Logger: log.Logger}
+}
+
+// Creates the logger of Default() and New(): JSON in ModeProduction, LogConsole otherwise.
+func defaultLogger(out io.Writer) zerolog.Logger {
 	if ModeProduction {
-		log.Logger = log.Output(zerolog.Logger{}.Output(out[0]))
+		return NewLogger(out, LogJSON)
 	}
+	return NewLogger(out, LogConsole)
+}
 
-	return &Router{Logger: log.Logger}
+/*
+NewLogger creates a logger writing to out in format.
+Assign it to Router.Logger to change the output of a router's logger:
+
+	r := gora.Default()
+	r.Logger = gora.NewLogger(os.Stdout, gora.LogJSON)
+*/
+func NewLogger(out io.Writer, format LogFormat) zerolog.Logger {
+	switch format {
+	case LogJSON:
+		return log.Output(out)
+	case LogConsoleNoColor:
+		return log.Output(zerolog.ConsoleWriter{Out: out, NoColor: true})
+	default:
+		return log.Output(zerolog.ConsoleWriter{Out: out})
+	}
+}
+
+// Returns the validator shared by all requests handled by this router.
+// Use it at startup to register custom validations, tag name functions
+// and translations used by Context.Validate.
+// The validator is created with the ValidationTag at the time of the first call.
+func (r *Router) Validator() *Validator {
+	r.validatorOnce.Do(func() {
+		r.validator = NewValidator(ValidationTag)
+	})
+	return r.validator
+}
+
+// Returns the router's validator for tagName, creating it on first use.
+// Returns Validator() if tagName is the ValidationTag.
+// Register custom validations on it before handling requests.
+func (r *Router) ValidatorFor(tagName string) *Validator {
+	if tagName == ValidationTag {
+		return r.Validator()
+	}
+
+	r.validatorsMu.Lock()
+	defer r.validatorsMu.Unlock()
+
+	if val, ok := r.validators[tagName]; ok {
+		return val
+	}
+
+	if r.validators == nil {
+		r.validators = make(map[string]*Validator)
+	}
+	val := NewValidator(tagName)
+	r.validators[tagName] = val
+	return val
 }
 
 // Apply middleware to the router.
@@ -127,43 +273,140 @@ func (r *Router) Use(middleware ...MiddlewareFunc) {
 	r.middleware = append(r.middleware, middleware...)
 }
 
+// Apply middleware that runs before route matching.
+// Unlike Use, pre-routing middleware can rewrite the request (e.g the path)
+// to affect which route matches. It also runs for unmatched requests.
+func (r *Router) Pre(middleware ...MiddlewareFunc) {
+	assert(len(middleware) > 0, "len(middleware) must be greater than 0")
+	r.pre = append(r.pre, middleware...)
+}
+
+// Like AddRoute but panics if pattern is not valid.
 func (r *Router) addRoute(pattern string, method string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	r.routes = append(r.routes, route{
-		pattern:    compileRegex(pattern),
+	if err := r.AddRoute(method, pattern, handler, middleware...); err != nil {
+		panic(err)
+	}
+}
+
+// Register a route for method, returning an error instead of panicking
+// if pattern is not valid. Use it to register routes built at runtime
+// e.g from configuration or a database.
+func (r *Router) AddRoute(method, pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) error {
+	regex, err := compilePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	r.register(route{
+		pattern:    regex,
+		path:       pattern,
 		handler:    handler,
 		method:     method,
 		middleware: middleware})
+	return nil
+}
+
+// Store rt in its method's bucket. Registration order within a method is
+// preserved since the first matching route wins.
+func (r *Router) register(rt route) {
+	if r.routes == nil {
+		r.routes = make(map[string][]route)
+	}
+
+	if _, ok := r.routes[rt.method]; !ok {
+		r.methods = append(r.methods, rt.method)
+	}
+	r.routes[rt.method] = append(r.routes[rt.method], rt)
+}
+
+// Calls fn to register routes only when not in ModeProduction.
+// Useful for debug routes that must never be exposed in production.
+//
+//	r.DevOnly(func(r *gora.Router) {
+//		r.GET("/debug/routes", debugRoutes)
+//	})
+func (r *Router) DevOnly(fn func(r *Router)) {
+	if !ModeProduction {
+		fn(r)
+	}
+}
+
+// Calls fn to register routes only in ModeProduction.
+func (r *Router) ProdOnly(fn func(r *Router)) {
+	if ModeProduction {
+		fn(r)
+	}
 }
 
 // Create a new router group.
 func (r *Router) Group(prefix string, middleware ...MiddlewareFunc) *RouterGroup {
-	return &RouterGroup{router: r, prefix: prefix, middleware: middleware}
+	return &RouterGroup{router: r, prefix: joinPaths("", prefix), middleware: middleware}
 }
 
 // Serves the http request. Implements the http.Handler interface.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Reject abusive paths before running the regex matchers.
+	maxPathLength := r.MaxPathLength
+	if maxPathLength == 0 {
+		maxPathLength = DefaultMaxPathLength
+	}
+
+	if maxPathLength > 0 && len(req.URL.Path) > maxPathLength {
+		http.Error(w, http.StatusText(http.StatusRequestURITooLong), http.StatusRequestURITooLong)
+		return
+	}
+
+	// Tag handler logs with the request they belong to.
+	logger := r.Logger.With().Str("method", req.Method).Str("path", req.URL.Path)
+	if requestID := req.Header.Get(RequestIDHeader); requestID != "" {
+		logger = logger.Str("request_id", requestID)
+	}
+
 	// Initialize the context the wraps the request and responseWriter.
 	ctx := &Context{
 		Request:   req,
 		Response:  &Writer{ResponseWriter: w},
 		Params:    make(map[string]string),
-		validator: NewValidator(ValidationTag),
+		validator: r.Validator(),
+		router:    r,
 		data:      make(map[string]any),
-		Logger:    r.Logger,
+		Logger:    logger.Logger(),
 		mu:        sync.RWMutex{},
 	}
 
-	// Loop through all routes until we find a match
-	for _, route := range r.routes {
-		if req.Method != route.method {
-			continue
+	// Run pre-routing middleware around route matching
+	chain(r.handleRoute, r.pre)(ctx)
+	ctx.Response.flushBuffer()
+	ctx.Response.writeHeaderNow()
+}
+
+// Wraps handler with each list of middleware in order, the last middleware
+// being the outermost. Every layer is skipped once the request is aborted.
+func chain(handler HandlerFunc, middleware ...[]MiddlewareFunc) HandlerFunc {
+	handler = skipIfAborted(handler)
+	for _, mws := range middleware {
+		for _, mw := range mws {
+			handler = skipIfAborted(mw(handler))
 		}
+	}
+	return handler
+}
 
-		// Extract path parameters if the route pattern contains placeholders (e.g. /users/:id)
-		path := req.URL.Path
-		if StrictSlash && path[len(path)-1] != '/' {
-			path += "/"
+func skipIfAborted(next HandlerFunc) HandlerFunc {
+	return func(ctx *Context) {
+		if ctx.aborted {
+			return
 		}
+		next(ctx)
+	}
+}
+
+// Returns the first route registered for method whose pattern matches path
+// and the path parameters extracted from path.
+func (r *Router) findRoute(method, path string) (*route, map[string]string) {
+	routes := r.routes[method]
+	for i := range routes {
+		route := &routes[i]
 
 		// Match route based on request path
 		if route.pattern.MatchString(path) {
@@ -174,20 +417,51 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 					params[name] = matches[i]
 				}
 			}
+			return route, params
+		}
+	}
+	return nil, nil
+}
 
-			// Add the path parameters to the request context
-			ctx.Params = params
+// Matches the request to a route and calls its handler.
+func (r *Router) handleRoute(ctx *Context) {
+	req := ctx.Request
 
-			// Initialize a handler
-			handler := route.handler
+	// Extract path parameters if the route pattern contains placeholders (e.g. /users/:id)
+	path := req.URL.Path
+	if StrictSlash && path[len(path)-1] != '/' {
+		path += "/"
+	}
 
-			// CombineMiddleare
-			middleware := append(r.middleware, route.middleware...)
-			for _, mw := range middleware {
-				handler = mw(handler)
-			}
+	route, params := r.findRoute(req.Method, path)
 
-			handler(ctx)
+	// Answer HEAD requests with the GET route unless a HEAD route is registered,
+	// sending the headers and status without the body.
+	if route == nil && req.Method == http.MethodHead {
+		route, params = r.findRoute(http.MethodGet, path)
+		ctx.Response.discardBody = route != nil
+	}
+
+	// Wildcard routes registered with Any match only if no route
+	// for the request method does.
+	if route == nil {
+		route, params = r.findRoute(methodAny, path)
+		ctx.Response.discardBody = route != nil && req.Method == http.MethodHead
+	}
+
+	if route != nil {
+		// Add the path parameters to the request context
+		ctx.Params = params
+		ctx.route = route.path
+
+		chain(route.handler, r.middleware, route.middleware)(ctx)
+		return
+	}
+
+	// Group fallbacks take precedence over the global catch-all route
+	for _, fallback := range r.groupNotFound {
+		if fallback.matches(req.URL.Path) {
+			fallback.handler(ctx)
 			return
 		}
 	}
@@ -199,7 +473,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// If no matching route is found, return a 404 Not Found response
-	http.NotFound(w, req)
+	http.NotFound(ctx.Response, req)
 }
 
 func (r *Router) GET(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
@@ -238,12 +512,97 @@ func (r *Router) HEAD(pattern string, handler HandlerFunc, middleware ...Middlew
 	r.addRoute(pattern, http.MethodHead, handler, middleware...)
 }
 
+// Internal method of wildcard routes registered with Any.
+const methodAny = "ANY"
+
+// Register handler for each of the given methods.
+// Each method is added as a separate route.
+func (r *Router) Handle(methods []string, pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	for _, method := range methods {
+		r.addRoute(pattern, method, handler, middleware...)
+	}
+}
+
+// Register handler for any request method.
+// Routes registered for a specific method on the same path take precedence.
+func (r *Router) Any(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	r.addRoute(pattern, methodAny, handler, middleware...)
+}
+
+// Set the handler used to send 401 Unauthorized responses
+// e.g by LoginRequired. Defaults to a plain-text response with the message.
+func (r *Router) SetUnauthorizedHandler(handler AbortHandler) {
+	r.unauthorized = handler
+}
+
+// Set the handler used to send 403 Forbidden responses
+// e.g by Cors and LoginRequired. Defaults to a plain-text response with the message.
+func (r *Router) SetForbiddenHandler(handler AbortHandler) {
+	r.forbidden = handler
+}
+
+/*
+SetTrustedProxies restricts the proxies whose X-Forwarded-For and X-Forwarded-Proto
+headers are honored by Context.ClientIP and Context.Scheme to the given CIDRs
+(or single IPs). Requests from other addresses use the direct connection values.
+Pass an empty slice to never trust forwarded headers.
+
+Until SetTrustedProxies is called, no proxy is trusted and forwarded headers are ignored.
+Call it when the server is deployed behind a reverse proxy or load balancer.
+
+	err := r.SetTrustedProxies([]string{"10.0.0.0/8", "127.0.0.1"})
+*/
+func (r *Router) SetTrustedProxies(cidrs []string) error {
+	proxies := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return fmt.Errorf("gora: invalid trusted proxy %q", cidr)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("gora: invalid trusted proxy %q: %w", cidr, err)
+		}
+		proxies = append(proxies, network)
+	}
+
+	r.trustedProxies = proxies
+	return nil
+}
+
+// Reports whether forwarded headers sent from ip are honored.
+func (r *Router) isTrustedProxy(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range r.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Base attributes applied to every cookie set by the cookie helpers.
+// See CookieOptions for how they combine with the attributes of each cookie.
+func (r *Router) CookieDefaults(options CookieOptions) {
+	r.cookieDefaults = options
+}
+
 // Connect a handler to be called if no pattern matches the request path.
 func (r *Router) NotFound(handler HandlerFunc, middleware ...MiddlewareFunc) {
-	for _, mw := range append(r.middleware, middleware...) {
-		handler = mw(handler)
-	}
-	r.notFound = handler
+	r.notFound = chain(handler, r.middleware, middleware)
 }
 
 // Serve static files with the http.FileServer
@@ -255,13 +614,12 @@ func (r *Router) NotFound(handler HandlerFunc, middleware ...MiddlewareFunc) {
 func (r *Router) Static(root, dirname, stripPrefix string) {
 	handler := http.StripPrefix(stripPrefix, http.FileServer(http.Dir(dirname)))
 	handlerFunc := func(ctx *Context) {
-		ctx.Response.WriteHeader(http.StatusOK)
 		handler.ServeHTTP(ctx.Response, ctx.Request)
 	}
 
 	// Compile regex
 	regex := regexp.MustCompile(root)
-	r.routes = append(r.routes, route{regex, handlerFunc, http.MethodGet, nil})
+	r.register(route{pattern: regex, path: root, handler: handlerFunc, method: http.MethodGet})
 }
 
 // Serve files in an embedded directory.
@@ -269,12 +627,13 @@ func (r *Router) Static(root, dirname, stripPrefix string) {
 // like svelte-kit, react, astro etc.
 // Serves index.html at the root of the file system as if it was mounted at root.
 // If ignore slice is not nil or empty, request path matching these routes are skipped.
-func (r *Router) StaticEmbedFS(staticEmbed StaticEmbed) {
-	fsys, err := fs.Sub(staticEmbed.EmbedFS, staticEmbed.Dirname)
-	if err != nil {
-		panic(err)
-	}
-
+//
+// Returns an error and registers no routes if the build directory or index file
+// can not be read e.g the frontend has not been built yet.
+//
+// Unmatched paths without a file extension (e.g /dashboard) are client-side routes
+// and are answered with the cached index file, without a file system lookup.
+func (r *Router) StaticEmbedFS(staticEmbed StaticEmbed) error {
 	// Set default arguments
 	if staticEmbed.IndexFile == "" {
 		staticEmbed.IndexFile = "index.html"
@@ -288,16 +647,27 @@ func (r *Router) StaticEmbedFS(staticEmbed StaticEmbed) {
 		staticEmbed.Route = "/"
 	}
 
+	fsys, err := fs.Sub(staticEmbed.EmbedFS, staticEmbed.Dirname)
+	if err != nil {
+		return err
+	}
+
 	// Initialize an http file system
 	httpfs := http.FS(fsys)
 	index, err := staticEmbed.EmbedFS.ReadFile(filepath.Join(staticEmbed.Dirname, staticEmbed.IndexFile))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	// Create a file server handler
 	handler := http.FileServer(httpfs)
 
+	// Serve the index from memory with the headers of ctx.HTML.
+	serveIndex := func(ctx *Context) {
+		ctx.Response.Header().Set("Content-Type", "text/html")
+		ctx.Response.Write(index)
+	}
+
 	// Helper to match request path to patterns to skip
 	skipPath := func(path string) bool {
 		skip := false
@@ -324,8 +694,7 @@ func (r *Router) StaticEmbedFS(staticEmbed StaticEmbed) {
 					return
 				}
 
-				ctx.Response.WriteHeader(http.StatusOK)
-				ctx.HTML(string(index))
+				serveIndex(ctx)
 			} else {
 				// IO Error
 				http.Error(ctx.Response, "something wrong happened!!", http.StatusInternalServerError)
@@ -341,15 +710,72 @@ func (r *Router) StaticEmbedFS(staticEmbed StaticEmbed) {
 		handler.ServeHTTP(ctx.Response, ctx.Request)
 	}
 
-	r.routes = append(r.routes, route{compileRegex(staticEmbed.Route), handlerFunc, "GET", nil})
+	r.register(route{
+		pattern: compileRegex(staticEmbed.Route),
+		path:    staticEmbed.Route,
+		handler: handlerFunc,
+		method:  http.MethodGet,
+	})
+
+	// Catch-all route for SPA mode. Paths without a file extension are
+	// client-side routes, so the index is served without opening the file system.
+	r.NotFound(func(ctx *Context) {
+		if filepath.Ext(ctx.Request.URL.Path) == "" && !skipPath(ctx.Request.URL.Path) {
+			serveIndex(ctx)
+			return
+		}
+		handlerFunc(ctx)
+	})
+	return nil
+}
 
-	// Catch-all route for SPA mode.
-	r.NotFound(handlerFunc)
+// Like StaticEmbedFS but panics if the build directory or index file can not be read.
+func (r *Router) MustStaticEmbedFS(staticEmbed StaticEmbed) {
+	if err := r.StaticEmbedFS(staticEmbed); err != nil {
+		panic(err)
+	}
+}
 
+// Serve a single file from fsys at the exact route.
+// The file is read once at registration and served from memory with the given
+// contentType, an ETag and Cache-Control headers. If contentType is empty, it is
+// detected from the file extension or content.
+// Panics if the file can not be read.
+//
+//	r.EmbedFile("/openapi.json", build, "build/openapi.json", "application/json")
+func (r *Router) EmbedFile(route string, fsys fs.FS, name string, contentType string) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic(err)
+	}
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = http.DetectContentType(data)
+		}
+	}
+
+	hash := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(hash[:16]) + `"`
+	modTime := time.Now()
+
+	r.GET(route, func(ctx *Context) {
+		ctx.Header("Content-Type", contentType)
+		ctx.Header("Cache-Control", "public, max-age=3600")
+		ctx.Header("ETag", etag)
+		http.ServeContent(ctx.Response, ctx.Request, name, modTime, bytes.NewReader(data))
+	})
 }
 
+// Returns all registered routes grouped by method.
+// Routes of each method are in registration order.
 func (r *Router) Routes() []route {
-	return r.routes
+	var routes []route
+	for _, method := range r.methods {
+		routes = append(routes, r.routes[method]...)
+	}
+	return routes
 }
 
 // Write data to a temporary file with given name.
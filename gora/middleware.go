@@ -1,7 +1,12 @@
 package gora
 
 import (
+	"errors"
+	"io"
+	"math/rand"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -11,61 +16,143 @@ import (
 )
 
 // Custom server recovery middleware.
+// Panics with an *HTTPError (e.g from Context.Check) are sent as JSON with the error's status code.
+// Other panics are sent as 500 Internal Server Error. See DefaultRecovery.
 func Recovery(next HandlerFunc) HandlerFunc {
-	return func(ctx *Context) {
-		defer func() {
-			if err := recover(); err != nil {
-				switch val := err.(type) {
+	return RecoveryWithStatus(DefaultRecovery)(next)
+}
+
+// RecoveryFunc maps a value recovered from a panic to the status and body of the response.
+type RecoveryFunc func(recovered any) (status int, body any)
+
+// The RecoveryFunc used by Recovery.
+// An *HTTPError anywhere in an error's chain maps to its code and {"error": message}.
+// Strings and other errors map to 500 with the message, anything else to 500 "Something went wrong!".
+func DefaultRecovery(recovered any) (status int, body any) {
+	var httpErr *HTTPError
+	if e, ok := recovered.(error); ok && errors.As(e, &httpErr) {
+		return httpErr.Code, map[string]string{"error": httpErr.Message}
+	}
+
+	switch val := recovered.(type) {
+	case string:
+		return http.StatusInternalServerError, val
+	case error:
+		return http.StatusInternalServerError, val.Error()
+	default:
+		return http.StatusInternalServerError, "Something went wrong!"
+	}
+}
+
+/*
+RecoveryWithStatus is like Recovery but lets mapping decide the status and body
+of the response for each panic value. String bodies are sent as HTML,
+other bodies as JSON and nil bodies send only the status.
+
+	r.Use(gora.RecoveryWithStatus(func(recovered any) (int, any) {
+		if errs, ok := recovered.(validator.ValidationErrors); ok {
+			return http.StatusBadRequest, gora.Map{"error": errs.Error()}
+		}
+		return gora.DefaultRecovery(recovered)
+	}))
+*/
+func RecoveryWithStatus(mapping RecoveryFunc) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+
+				status, body := mapping(recovered)
+				event := ctx.Logger.Info().Int("status", status)
+				if e, ok := recovered.(error); ok {
+					event = event.Err(e)
+				} else {
+					event = event.Interface("message", recovered)
+				}
+
+				if status >= http.StatusInternalServerError {
+					event.Msg("internal server error")
+				} else {
+					event.Msg("http error")
+				}
+
+				switch b := body.(type) {
+				case nil:
+					ctx.Status(status)
 				case string:
-					ctx.Logger.Info().Str("message", val).Msg("internal server error")
-					ctx.Status(http.StatusInternalServerError).HTML(val)
-				case error:
-					ctx.Logger.Info().Str("message", val.Error()).Msg("internal server error")
-					ctx.Status(http.StatusInternalServerError).HTML(val.Error())
+					ctx.Status(status).HTML(b)
 				default:
-					ctx.Logger.Info().Interface("message", val).Msg("internal server error")
-					ctx.Status(http.StatusInternalServerError).HTML("Something went wrong!")
+					ctx.Status(status).JSON(b)
 				}
-			}
-		}()
+			}()
 
-		next(ctx)
+			next(ctx)
+		}
 	}
 }
 
 // A simple logging middleware.
 // Logs the Request Method, Path, IP, Browser, Latency.
 func Logger(next HandlerFunc) HandlerFunc {
+	return LoggerWithConfig(LoggerConfig{})(next)
+}
+
+// LoggerConfig configures the LoggerWithConfig middleware.
+type LoggerConfig struct {
+	// Fraction of successful (2xx) requests to log, between 0 and 1.
+	// e.g 0.1 logs about 1 in 10 successful requests.
+	// All other responses (e.g 4xx and 5xx) are always logged.
+	// Values <= 0 or >= 1 log every request.
+	Sample float64
+}
+
+// Like Logger but configurable with LoggerConfig e.g to sample successful requests.
+func LoggerWithConfig(config LoggerConfig) MiddlewareFunc {
 	zerolog.TimeFieldFormat = time.RFC3339
+	sampled := config.Sample > 0 && config.Sample < 1
 
-	return func(ctx *Context) {
-		ua := useragent.Parse(ctx.Request.Header.Get("User-Agent"))
-		// Get the IP address of the client
-		var ip string = ctx.Request.RemoteAddr
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			start := time.Now()
+			next(ctx)
+			latency := time.Since(start).String()
 
-		if strings.HasPrefix(ip, "[::1]") {
-			ip = "localhost"
-		} else {
-			hostPortpair := strings.Split(ip, ":")
-			if len(hostPortpair) == 2 {
-				ip = hostPortpair[0]
+			statusCode := ctx.StatusCode()
+			if statusCode == 0 {
+				statusCode = http.StatusOK
 			}
-		}
 
-		start := time.Now()
-		next(ctx)
-		latency := time.Since(start).String()
-		ctx.Logger.Info().
-			Str("method", ctx.Request.Method).
-			Str("path", ctx.Request.URL.Path).
-			Int("statusCode", ctx.StatusCode()).
-			Str("ip", ip).
-			Str("browser", ua.Name).
-			Str("device", ua.Device).
-			Str("os", ua.OS).
-			Str("os-version", ua.OSVersion).
-			Str("latency", latency).
-			Msg("")
+			if sampled && statusCode >= 200 && statusCode < 300 && rand.Float64() >= config.Sample {
+				return
+			}
+
+			ua := useragent.Parse(ctx.Request.Header.Get("User-Agent"))
+			// Get the IP address of the client
+			var ip string = ctx.Request.RemoteAddr
+
+			if strings.HasPrefix(ip, "[::1]") {
+				ip = "localhost"
+			} else {
+				hostPortpair := strings.Split(ip, ":")
+				if len(hostPortpair) == 2 {
+					ip = hostPortpair[0]
+				}
+			}
+
+			// The method and path are fields of the request's logger.
+			ctx.Logger.Info().
+				Int("statusCode", statusCode).
+				Str("ip", ip).
+				Str("browser", ua.Name).
+				Str("device", ua.Device).
+				Str("os", ua.OS).
+				Str("os-version", ua.OSVersion).
+				Str("latency", latency).
+				Msg("")
+		}
 	}
 }
 
@@ -91,7 +178,9 @@ type CorsConfig struct {
 		The Access-Control-Allow-Origin response header indicates whether the response can be
 		shared with requesting code from the given origin.
 
-		Passing in []string{"*"} will allow all origins
+		Passing in []string{"*"} will allow all origins.
+		A leading *. allows all subdomains e.g https://*.example.com
+		allows https://app.example.com but not https://example.com.
 	*/
 	AllowedOrigins []string
 
@@ -126,21 +215,43 @@ type CorsConfig struct {
 
 func (m *CorsConfig) isOriginAllowed(origin string) bool {
 	for _, o := range m.AllowedOrigins {
-		if o == "*" || o == origin {
+		if o == "*" || o == origin || matchWildcardOrigin(o, origin) {
 			return true
 		}
 	}
 	return false
 }
 
+// Matches origin against a pattern with a *. subdomain wildcard
+// e.g https://*.example.com matches https://app.example.com and
+// https://eu.app.example.com but not https://example.com.
+func matchWildcardOrigin(pattern, origin string) bool {
+	i := strings.Index(pattern, "*.")
+	if i == -1 {
+		return false
+	}
+
+	scheme, suffix := pattern[:i], pattern[i+1:]
+	if len(origin) <= len(scheme)+len(suffix) ||
+		!strings.HasPrefix(origin, scheme) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+
+	subdomain := origin[len(scheme) : len(origin)-len(suffix)]
+	return !strings.ContainsAny(subdomain, "/:@")
+}
+
 // Cors middleware.
 // m CorsConfig configures the CORS response headers.
+// Requests without an Origin header (e.g server-to-server requests) and requests
+// from origins not in m.AllowedOrigins (including same-origin requests) are passed
+// through without CORS headers, leaving the browser to enforce the policy.
 func Cors(m CorsConfig) MiddlewareFunc {
 	return func(next HandlerFunc) HandlerFunc {
 		return func(c *Context) {
 			origin := c.Request.Header.Get("Origin")
 			if origin == "" || !m.isOriginAllowed(origin) {
-				c.Abort(http.StatusForbidden, "Forbidden")
+				next(c)
 				return
 			}
 
@@ -163,3 +274,240 @@ func Cors(m CorsConfig) MiddlewareFunc {
 		}
 	}
 }
+
+// RequireHTTPS rejects plain-HTTP requests.
+// A request is considered secure if it was received over TLS or
+// the X-Forwarded-Proto header set by a trusted proxy is https.
+// See Router.SetTrustedProxies.
+// If redirect is true, insecure requests are redirected to the https:// URL
+// with 301 Moved Permanently, otherwise they are aborted with 403 Forbidden.
+func RequireHTTPS(redirect bool) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.Scheme() == "https" {
+				next(c)
+				return
+			}
+
+			if redirect {
+				c.Redirect("https://" + c.Request.Host + c.Request.URL.RequestURI())
+				c.AbortRequest()
+				return
+			}
+
+			c.Forbidden("HTTPS required")
+		}
+	}
+}
+
+/*
+Accepts restricts a route to request bodies with one of the given media types.
+Requests with a missing or different Content-Type are aborted with
+415 Unsupported Media Type before the handler runs.
+Media type parameters like charset are ignored and a type like "text/*" matches any subtype.
+
+	r.POST("/users", createUser, gora.Accepts("application/json"))
+*/
+func Accepts(types ...string) MiddlewareFunc {
+	assert(len(types) > 0, "Accepts requires at least one media type")
+
+	allowed := make([]string, len(types))
+	for i, t := range types {
+		allowed[i] = strings.ToLower(strings.TrimSpace(t))
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			mediaType, _, err := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+			if err == nil && isAcceptedMediaType(mediaType, allowed) {
+				next(c)
+				return
+			}
+
+			c.Abort(http.StatusUnsupportedMediaType, http.StatusText(http.StatusUnsupportedMediaType))
+		}
+	}
+}
+
+// Reports whether mediaType matches one of allowed, which may contain type/* wildcards.
+func isAcceptedMediaType(mediaType string, allowed []string) bool {
+	for _, t := range allowed {
+		if t == mediaType {
+			return true
+		}
+
+		if strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Context key under which ValidateRequest stores the bound request body.
+const RequestBodyKey = "requestBody"
+
+/*
+ValidateRequest declares T as the request body type of a route.
+The body is bound (as XML if the Content-Type is XML, otherwise JSON) into a new T
+and validated before the handler runs. Bind errors abort with 400 Bad Request and
+validation errors are sent with c.ValidationError.
+On success, the bound *T is stored on the context with the key RequestBodyKey.
+
+	r.POST("/users", func(c *gora.Context) {
+		user := c.MustGet(gora.RequestBodyKey).(*CreateUser)
+		...
+	}, gora.ValidateRequest[CreateUser]())
+*/
+func ValidateRequest[T any]() MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			body := new(T)
+
+			var err error
+			if strings.Contains(c.Request.Header.Get("Content-Type"), "xml") {
+				err = c.BindXML(body)
+			} else {
+				err = c.BindJSON(body)
+			}
+
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = ErrEmptyRequestBody
+				}
+				c.AbortWithError(http.StatusBadRequest, err)
+				return
+			}
+
+			errs, err := c.ValidateSafe(body)
+			if err != nil {
+				c.AbortWithError(http.StatusInternalServerError, err)
+				return
+			}
+
+			if errs != nil {
+				c.ValidationError(errs)
+				c.AbortRequest()
+				return
+			}
+
+			c.Set(RequestBodyKey, body)
+			next(c)
+		}
+	}
+}
+
+// SlowRequestLogger logs requests that take longer than threshold at warn level,
+// with the method, matched route, path, query, path parameters and latency.
+// Fast requests are only timed and not logged.
+func SlowRequestLogger(threshold time.Duration) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) {
+			start := time.Now()
+			next(ctx)
+			latency := time.Since(start)
+
+			if latency <= threshold {
+				return
+			}
+
+			ctx.Logger.Warn().
+				Str("method", ctx.Request.Method).
+				Str("route", ctx.Route()).
+				Str("path", ctx.Request.URL.Path).
+				Str("query", ctx.Request.URL.RawQuery).
+				Interface("params", ctx.Params).
+				Int("statusCode", ctx.StatusCode()).
+				Str("latency", latency.String()).
+				Str("threshold", threshold.String()).
+				Msg("slow request")
+		}
+	}
+}
+
+// ConcurrencyLimit caps the number of requests handled concurrently by the
+// routes it is applied to. Requests over the limit are aborted immediately
+// with 503 Service Unavailable and a Retry-After header rather than queued.
+// Apply it globally with r.Use or per route/group for a shared downstream resource.
+func ConcurrencyLimit(max int) MiddlewareFunc {
+	return ConcurrencyLimitWait(max, 0)
+}
+
+// Like ConcurrencyLimit but requests over the limit wait up to timeout
+// for a slot before being aborted with 503 Service Unavailable.
+func ConcurrencyLimitWait(max int, timeout time.Duration) MiddlewareFunc {
+	assert(max > 0, "max concurrent requests must be greater than 0")
+	sem := make(chan struct{}, max)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			select {
+			case sem <- struct{}{}:
+			default:
+				if !acquireWithTimeout(c, sem, timeout) {
+					c.Header("Retry-After", "1")
+					c.Abort(http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+					return
+				}
+			}
+
+			defer func() { <-sem }()
+			next(c)
+		}
+	}
+}
+
+// Waits up to timeout for a slot in sem.
+// Returns false if the timeout expires or the request is cancelled first.
+func acquireWithTimeout(c *Context, sem chan struct{}, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
+
+// StripPrefix removes prefix from the request path before route matching,
+// like http.StripPrefix. Requests whose path does not start with prefix get 404 Not Found.
+// It must be registered with r.Pre to affect routing e.g behind a proxy
+// forwarding /service-a/... to routes defined without the prefix.
+//
+//	r.Pre(gora.StripPrefix("/service-a"))
+func StripPrefix(prefix string) MiddlewareFunc {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			path := c.Request.URL.Path
+			if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+				http.NotFound(c.Response, c.Request)
+				c.AbortRequest()
+				return
+			}
+
+			// Shallow copy the request and URL like http.StripPrefix.
+			req := new(http.Request)
+			*req = *c.Request
+			req.URL = new(url.URL)
+			*req.URL = *c.Request.URL
+
+			req.URL.Path = strings.TrimPrefix(path, prefix)
+			if req.URL.Path == "" {
+				req.URL.Path = "/"
+			}
+			req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, prefix)
+
+			c.Request = req
+			next(c)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package gora
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+type proxyConfig struct {
+	stripPrefix  string
+	headers      http.Header
+	errorHandler func(c *Context, err error)
+}
+
+// ProxyOption configures the ReverseProxy handler.
+type ProxyOption func(*proxyConfig)
+
+// Strip prefix from the request path before forwarding it upstream.
+// e.g with prefix "/api/users", /api/users/10 is forwarded as /10.
+func ProxyStripPrefix(prefix string) ProxyOption {
+	return func(pc *proxyConfig) {
+		pc.stripPrefix = prefix
+	}
+}
+
+// Set a header on every request forwarded upstream.
+// Replaces any value sent by the client for the same key.
+func ProxyHeader(key, value string) ProxyOption {
+	return func(pc *proxyConfig) {
+		pc.headers.Set(key, value)
+	}
+}
+
+// Handle errors reaching the upstream service.
+// The default handler logs the error and aborts with 502 Bad Gateway.
+func ProxyErrorHandler(handler func(c *Context, err error)) ProxyOption {
+	return func(pc *proxyConfig) {
+		pc.errorHandler = handler
+	}
+}
+
+func defaultProxyErrorHandler(c *Context, err error) {
+	c.Logger.Error().Err(err).Str("path", c.Request.URL.Path).Msg("reverse proxy error")
+	c.Abort(http.StatusBadGateway, http.StatusText(http.StatusBadGateway))
+}
+
+/*
+ReverseProxy returns a handler that forwards requests to the upstream target
+using httputil.NewSingleHostReverseProxy. Panics if target is not a valid URL.
+
+Path parameters match a single segment, so to forward every path under a prefix
+(e.g /api/users/10/orders) register the proxy as the group's NotFound fallback.
+It receives requests of any method under the prefix that match no other route.
+
+	users := r.Group("/api/users")
+	users.NotFound(gora.ReverseProxy("http://localhost:9000",
+		gora.ProxyStripPrefix("/api/users"),
+		gora.ProxyHeader("X-Gateway", "gora")))
+*/
+func ReverseProxy(target string, options ...ProxyOption) HandlerFunc {
+	targetURL, err := url.Parse(target)
+	if err != nil {
+		panic(err)
+	}
+
+	config := &proxyConfig{
+		headers:      make(http.Header),
+		errorHandler: defaultProxyErrorHandler,
+	}
+
+	for _, opt := range options {
+		opt(config)
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		if config.stripPrefix != "" {
+			req.URL.Path = strings.TrimPrefix(req.URL.Path, config.stripPrefix)
+			if !strings.HasPrefix(req.URL.Path, "/") {
+				req.URL.Path = "/" + req.URL.Path
+			}
+			req.URL.RawPath = ""
+		}
+
+		director(req)
+		req.Host = targetURL.Host
+
+		for key, values := range config.headers {
+			req.Header[key] = values
+		}
+	}
+
+	return func(c *Context) {
+		// Copy the proxy so that the error handler can reach this request's context.
+		rp := *proxy
+		rp.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			config.errorHandler(c, err)
+		}
+		rp.ServeHTTP(c.Response, c.Request)
+	}
+}
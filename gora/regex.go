@@ -6,8 +6,15 @@ import (
 	"strings"
 )
 
+// Canonical 8-4-4-4-12 hex form of a UUID.
+const uuidPattern = "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"
+
+var uuidRegex = regexp.MustCompile("^" + uuidPattern + "$")
+
 // convert a pathPrefix into a valid regex string.
-// Supports custom types: int, str, float, bool, date, datetime
+// Supports custom types: int, str, float, bool, date, datetime, uuid,
+// enum e.g {period:enum:daily|weekly|monthly}
+// and regex e.g {code:regex([A-Z]{2})}
 func pathPrefixToRegex(pathPrefix string) (string, error) {
 	// Split the path prefix into its individual segments
 	segments := strings.Split(pathPrefix, "/")
@@ -27,7 +34,7 @@ func pathPrefixToRegex(pathPrefix string) (string, error) {
 			if strings.Contains(paramName, ":") {
 				// Extract the parameter name and type from the segment
 				var paramType string
-				params := strings.Split(paramName, ":")
+				params := strings.SplitN(paramName, ":", 2)
 				paramName = params[0]
 				paramType = params[1]
 
@@ -44,6 +51,23 @@ func pathPrefixToRegex(pathPrefix string) (string, error) {
 					regex += "(?P<" + paramName + ">\\d{4}-\\d{2}-\\d{2})"
 				} else if paramType == "datetime" {
 					regex += "(?P<" + paramName + ">\\d{4}-\\d{2}-\\d{2} \\d{2}:\\d{2}:\\d{2})"
+				} else if paramType == "uuid" {
+					regex += "(?P<" + paramName + ">" + uuidPattern + ")"
+				} else if strings.HasPrefix(paramType, "regex(") && strings.HasSuffix(paramType, ")") {
+					pattern := paramType[len("regex(") : len(paramType)-1]
+					if _, err := regexp.Compile(pattern); pattern == "" || err != nil {
+						return "", errors.New("invalid regex parameter: " + paramName)
+					}
+					regex += "(?P<" + paramName + ">" + pattern + ")"
+				} else if strings.HasPrefix(paramType, "enum:") {
+					members := strings.Split(strings.TrimPrefix(paramType, "enum:"), "|")
+					for i, member := range members {
+						if member == "" {
+							return "", errors.New("invalid enum parameter: " + paramName)
+						}
+						members[i] = regexp.QuoteMeta(member)
+					}
+					regex += "(?P<" + paramName + ">" + strings.Join(members, "|") + ")"
 				} else {
 					return "", errors.New("invalid parameter type: " + paramType)
 				}
@@ -74,9 +98,18 @@ func pathPrefixToRegex(pathPrefix string) (string, error) {
 // Compiles a regex pattern string into a regexp.Regexp
 // panics if pattern is not valid.
 func compileRegex(pat string) *regexp.Regexp {
-	regex, err := pathPrefixToRegex(pat)
+	regex, err := compilePattern(pat)
 	if err != nil {
 		panic(err)
 	}
-	return regexp.MustCompile(regex)
+	return regex
+}
+
+// Like compileRegex but returns an error if pattern is not valid.
+func compilePattern(pat string) (*regexp.Regexp, error) {
+	regex, err := pathPrefixToRegex(pat)
+	if err != nil {
+		return nil, err
+	}
+	return regexp.Compile(regex)
 }
@@ -2,6 +2,8 @@ package gora
 
 import (
 	"net/http"
+	"sort"
+	"strings"
 )
 
 // Router group allows for nesting of url routes.
@@ -12,12 +14,55 @@ type RouterGroup struct {
 	middleware []MiddlewareFunc
 }
 
+// Registers the route under the group's prefix. Group middleware
+// wraps the handler inside any route-level middleware.
 func (g *RouterGroup) addRoute(pattern string, method string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.router.routes = append(g.router.routes, route{
+	pattern = joinPaths(g.prefix, pattern)
+	g.router.register(route{
 		pattern:    compileRegex(pattern),
+		path:       pattern,
 		handler:    handler,
 		method:     method,
-		middleware: middleware})
+		middleware: concatMiddleware(g.middleware, middleware)})
+}
+
+// Joins prefix and pattern, collapsing duplicate slashes and ensuring
+// a single leading slash. Slashes inside {} parameters are left untouched.
+// e.g joinPaths("/api/", "/users") == "/api/users"
+func joinPaths(prefix, pattern string) string {
+	joined := prefix + "/" + pattern
+	if pattern == "" {
+		joined = prefix
+	}
+
+	var b strings.Builder
+	b.Grow(len(joined) + 1)
+	b.WriteByte('/')
+
+	depth, last := 0, byte('/')
+	for i := 0; i < len(joined); i++ {
+		ch := joined[i]
+		switch ch {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 && last == '/' {
+				continue
+			}
+		}
+		b.WriteByte(ch)
+		last = ch
+	}
+	return b.String()
+}
+
+// Returns a new slice so that groups never share a backing array.
+func concatMiddleware(a, b []MiddlewareFunc) []MiddlewareFunc {
+	middleware := make([]MiddlewareFunc, 0, len(a)+len(b))
+	middleware = append(middleware, a...)
+	return append(middleware, b...)
 }
 
 func (g *RouterGroup) Use(middleware ...MiddlewareFunc) {
@@ -25,56 +70,81 @@ func (g *RouterGroup) Use(middleware ...MiddlewareFunc) {
 }
 
 func (g *RouterGroup) GET(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodGet, handler, middleware...)
+	g.addRoute(pattern, http.MethodGet, handler, middleware...)
 }
 
 func (g *RouterGroup) POST(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodPost, handler, middleware...)
+	g.addRoute(pattern, http.MethodPost, handler, middleware...)
 }
 
 func (g *RouterGroup) PUT(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodPut, handler, middleware...)
+	g.addRoute(pattern, http.MethodPut, handler, middleware...)
 }
 
 func (g *RouterGroup) PATCH(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodPatch, handler, middleware...)
+	g.addRoute(pattern, http.MethodPatch, handler, middleware...)
 }
 
 func (g *RouterGroup) DELETE(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodDelete, handler, middleware...)
+	g.addRoute(pattern, http.MethodDelete, handler, middleware...)
 }
 
 // Other methods
 func (g *RouterGroup) OPTIONS(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodOptions, handler, middleware...)
+	g.addRoute(pattern, http.MethodOptions, handler, middleware...)
 }
 
 func (g *RouterGroup) CONNECT(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodConnect, handler, middleware...)
+	g.addRoute(pattern, http.MethodConnect, handler, middleware...)
 }
 
 func (g *RouterGroup) TRACE(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodTrace, handler, middleware...)
+	g.addRoute(pattern, http.MethodTrace, handler, middleware...)
 }
 
 func (g *RouterGroup) HEAD(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	g.addRoute(g.prefix+pattern, http.MethodHead, handler, middleware...)
+	g.addRoute(pattern, http.MethodHead, handler, middleware...)
+}
+
+// Register handler for each of the given methods.
+func (g *RouterGroup) Handle(methods []string, pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	for _, method := range methods {
+		g.addRoute(pattern, method, handler, middleware...)
+	}
+}
+
+// Register handler for any request method.
+func (g *RouterGroup) Any(pattern string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+	g.addRoute(pattern, methodAny, handler, middleware...)
 }
 
 func (g *RouterGroup) Static(pattern, dirname, stripPrefix string) {
 	handler := http.StripPrefix(stripPrefix, http.FileServer(http.Dir(dirname)))
 	handlerFunc := func(ctx *Context) {
-		ctx.Response.WriteHeader(http.StatusOK)
 		handler.ServeHTTP(ctx.Response, ctx.Request)
 	}
-	g.addRoute(g.prefix+pattern, http.MethodGet, handlerFunc, nil)
+	g.addRoute(pattern, http.MethodGet, handlerFunc)
+}
+
+// Connect a handler to be called if a request path has the group's prefix
+// but matches no route. e.g a JSON 404 for unmatched /api/* paths.
+// Group fallbacks are consulted before the router's NotFound handler,
+// with the most specific (longest) prefix first.
+func (g *RouterGroup) NotFound(handler HandlerFunc, middleware ...MiddlewareFunc) {
+	handler = chain(handler, g.router.middleware, g.middleware, middleware)
+	fallback := groupFallback{prefix: strings.TrimSuffix(g.prefix, "/"), handler: handler}
+	fallbacks := append(g.router.groupNotFound, fallback)
+	sort.SliceStable(fallbacks, func(i, j int) bool {
+		return len(fallbacks[i].prefix) > len(fallbacks[j].prefix)
+	})
+	g.router.groupNotFound = fallbacks
 }
 
 // Create a new router group on a router group.
 func (g *RouterGroup) Group(prefix string, middleware ...MiddlewareFunc) *RouterGroup {
 	return &RouterGroup{
 		router:     g.router,
-		prefix:     g.prefix + prefix,
-		middleware: append(g.middleware, middleware...),
+		prefix:     joinPaths(g.prefix, prefix),
+		middleware: concatMiddleware(g.middleware, middleware),
 	}
 }
@@ -2,37 +2,83 @@ package gora
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
-func (r *Router) waitForGracefulShutdown(srv *http.Server) {
-	// Wait for interrupt signal to gracefully shutdown the server with
+// ServerConfig configures the servers started by Run and RunBoth.
+type ServerConfig struct {
+	/*
+		Serve HTTP/2 over cleartext TCP (h2c) on plain HTTP servers, in addition to HTTP/1.1.
+		Lets clients with prior knowledge of HTTP/2 (e.g gRPC clients) connect without TLS.
+		The router is wrapped with h2c.NewHandler, so requests reach the same routes
+		and middleware regardless of the protocol.
+
+		h2c is unencrypted. Only enable it on internal networks, e.g behind a load balancer.
+		TLS servers (RunTLS) negotiate HTTP/2 without this setting.
+	*/
+	EnableH2C bool
+}
+
+func (r *Router) waitForGracefulShutdown(servers ...*http.Server) {
+	// Wait for interrupt signal to gracefully shutdown the servers with
 	// a timeout of 5 seconds.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	r.Logger.Info().Msg("Shutdown server ...")
+	r.shutdown(servers...)
+}
 
+// Gracefully shuts down servers concurrently with a timeout of 5 seconds.
+func (r *Router) shutdown(servers ...*http.Server) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
-		r.Logger.Debug().Msgf("Server shutdown error: %v", err)
-	} else {
-		r.Logger.Debug().Msg("Server shutdown gracefully")
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+
+			if err := srv.Shutdown(ctx); err != nil {
+				r.Logger.Debug().Msgf("Server %s shutdown error: %v", srv.Addr, err)
+			} else {
+				r.Logger.Debug().Msgf("Server %s shutdown gracefully", srv.Addr)
+			}
+		}(srv)
 	}
+	wg.Wait()
 }
 
-func (r *Router) Run(addr string) {
-	srv := &http.Server{Addr: addr,
+func (r *Router) newServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:           addr,
 		Handler:        r,
 		MaxHeaderBytes: 1 << 20,
 		ReadTimeout:    30 * time.Second,
 		WriteTimeout:   60 * time.Second,
 	}
+}
+
+// Returns a server for plain HTTP, serving h2c if enabled in r.ServerConfig.
+func (r *Router) newHTTPServer(addr string) *http.Server {
+	srv := r.newServer(addr)
+	if r.ServerConfig.EnableH2C {
+		srv.Handler = h2c.NewHandler(r, &http2.Server{})
+	}
+	return srv
+}
+
+func (r *Router) Run(addr string) {
+	srv := r.newHTTPServer(addr)
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -44,12 +90,7 @@ func (r *Router) Run(addr string) {
 }
 
 func (r *Router) RunTLS(addr string, certFile, keyFile string) {
-	srv := &http.Server{
-		Addr:           addr,
-		Handler:        r,
-		MaxHeaderBytes: 1 << 20,
-		ReadTimeout:    30 * time.Second,
-		WriteTimeout:   60 * time.Second}
+	srv := r.newServer(addr)
 
 	go func() {
 		if err := srv.ListenAndServeTLS(certFile, keyFile); err != nil && err != http.ErrServerClosed {
@@ -59,3 +100,56 @@ func (r *Router) RunTLS(addr string, certFile, keyFile string) {
 
 	r.waitForGracefulShutdown(srv)
 }
+
+// Serve plain HTTP on httpAddr and HTTPS on httpsAddr with the same router.
+// e.g HTTP on :8080 for internal health checks and HTTPS on :8443 for external traffic.
+// Blocks until an interrupt signal is received, then gracefully shuts down both servers.
+// If either server fails, the other is shut down and the error is logged with Fatal.
+func (r *Router) RunBoth(httpAddr, httpsAddr string, certFile, keyFile string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	httpLn, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		r.Logger.Fatal().Msgf("listen: %s", err)
+	}
+
+	httpsLn, err := net.Listen("tcp", httpsAddr)
+	if err != nil {
+		httpLn.Close()
+		r.Logger.Fatal().Msgf("listen: %s", err)
+	}
+
+	if err := r.serveBoth(ctx, httpLn, httpsLn, certFile, keyFile); err != nil {
+		r.Logger.Fatal().Msgf("listen: %s", err)
+	}
+}
+
+// Serves plain HTTP on httpLn and HTTPS on httpsLn until ctx is done or
+// either server fails, then shuts down both.
+// Returns the error of the server that failed, nil if ctx is done first.
+func (r *Router) serveBoth(ctx context.Context, httpLn, httpsLn net.Listener, certFile, keyFile string) error {
+	// ServeTLS does not close the listener if the certificate can not be loaded.
+	defer httpsLn.Close()
+
+	httpSrv := r.newHTTPServer(httpLn.Addr().String())
+	httpsSrv := r.newServer(httpsLn.Addr().String())
+
+	errs := make(chan error, 2)
+	go func() { errs <- httpSrv.Serve(httpLn) }()
+	go func() { errs <- httpsSrv.ServeTLS(httpsLn, certFile, keyFile) }()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		r.Logger.Info().Msg("Shutdown server ...")
+	case err = <-errs:
+		r.Logger.Error().Err(err).Msg("server stopped, shutting down")
+	}
+
+	r.shutdown(httpSrv, httpsSrv)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
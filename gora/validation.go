@@ -45,6 +45,28 @@ func (val *Validator) SetTagName(tagName string) {
 	val.validator.SetTagName(tagName)
 }
 
+// Register a custom validation function for tag.
+// Not goroutine safe. Register validations before handling requests.
+func (val *Validator) RegisterValidation(tag string, fn validator.Func, callValidationEvenIfNull ...bool) error {
+	return val.validator.RegisterValidation(tag, fn, callValidationEvenIfNull...)
+}
+
+// Register a function to get alternate names for struct fields e.g from the json tag.
+func (val *Validator) RegisterTagNameFunc(fn validator.TagNameFunc) {
+	val.validator.RegisterTagNameFunc(fn)
+}
+
+// Register a translation for tag using the validator's 'en' translator.
+func (val *Validator) RegisterTranslation(tag string, registerFn validator.RegisterTranslationsFunc,
+	translationFn validator.TranslationFunc) error {
+	return val.validator.RegisterTranslation(tag, val.trans, registerFn, translationFn)
+}
+
+// Returns the underlying go-playground validator for advanced configuration.
+func (val *Validator) Engine() *validator.Validate {
+	return val.validator
+}
+
 // Validates structs inside a slice, returns validator.ValidationErrors
 // Not that it returns the first encountered error
 func (val *Validator) validateSlice(value reflect.Value) error {
@@ -62,32 +84,67 @@ func (val *Validator) validateSlice(value reflect.Value) error {
 // Validates structs, pointers to structs and slices/arrays of structs
 // Validate will panic if obj is not struct, slice, array or pointers to the same.
 func (val *Validator) Validate(obj any) validator.ValidationErrors {
+	errs, err := val.ValidateSafe(obj)
+	if err != nil {
+		panic(err)
+	}
+	return errs
+}
+
+// Like Validate but returns errUnsupportedType as an error instead of panicking
+// if obj is not struct, slice, array or pointers to the same.
+func (val *Validator) ValidateSafe(obj any) (validator.ValidationErrors, error) {
 	value := reflect.ValueOf(obj)
 
 	var err error
 	switch value.Kind() {
 	case reflect.Ptr:
 		elem := value.Elem()
+		if !elem.IsValid() {
+			return nil, errUnsupportedType
+		}
+
 		switch reflect.ValueOf(elem.Interface()).Kind() {
 		case reflect.Struct:
 			err = val.validator.Struct(elem.Interface())
 		case reflect.Slice, reflect.Array:
 			err = val.validateSlice(elem)
 		default:
-			panic(errUnsupportedType)
+			return nil, errUnsupportedType
 		}
 	case reflect.Struct:
 		err = val.validator.Struct(value.Interface())
 	case reflect.Slice, reflect.Array:
 		err = val.validateSlice(value)
 	default:
-		panic(errUnsupportedType)
+		return nil, errUnsupportedType
 	}
 
 	if err != nil {
-		return err.(validator.ValidationErrors)
-	} else {
-		return nil
+		return err.(validator.ValidationErrors), nil
+	}
+	return nil, nil
+}
+
+/*
+WithValidationTag makes Context.Validate (and the binding helpers that validate)
+use the validation tag tagName for the routes or groups it is applied to,
+e.g for structs tagged with binding instead of validate.
+
+	api.POST("/import", importHandler, gora.WithValidationTag("binding"))
+
+The validator for each tag is created once per router, see Router.ValidatorFor.
+*/
+func WithValidationTag(tagName string) MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) {
+			if c.router != nil {
+				c.validator = c.router.ValidatorFor(tagName)
+			} else {
+				c.validator = NewValidator(tagName)
+			}
+			next(c)
+		}
 	}
 }
 
@@ -107,8 +164,13 @@ func IsValidEmail(email string) bool {
 }
 
 // Sends translated error messages from go-playground validator using en local
-// as JSON.
+// as JSON. If the client prefers XML (per the Accept or Content-Type header),
+// the errors are sent as XML instead.
 func (c *Context) ValidationError(err validator.ValidationErrors) {
 	errMap := c.validator.TranslateErrors(err)
+	if c.wantsXML() {
+		c.Status(http.StatusBadRequest).XML(toXMLValidationErrors(errMap))
+		return
+	}
 	c.Status(http.StatusBadRequest).JSON(errMap)
 }
@@ -2,7 +2,6 @@ package ws
 
 import (
 	"bytes"
-	"log"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -21,6 +20,9 @@ const (
 	// Maximum message size allowed from peer.
 	// Defaults to: 10MB
 	maxMessageSize = 10 << 20
+
+	// Default capacity of the client's buffered send channel.
+	defaultSendBufferSize = 256
 )
 
 var (
@@ -28,11 +30,6 @@ var (
 	space   = []byte{' '}
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  0,
-	WriteBufferSize: 0,
-}
-
 // Client is a middleman between the websocket connection and the hub.
 type Client struct {
 	hub *WebsocketHandler
@@ -42,6 +39,20 @@ type Client struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// Identifies the client for SendTo. May be empty.
+	id string
+
+	// Rooms joined by the client. Guarded by hub.mu.
+	rooms map[string]bool
+
+	// Set once the client is removed from the hub. Guarded by hub.mu.
+	closed bool
+}
+
+// Returns the id of the client or an empty string if it has none.
+func (c *Client) ID() string {
+	return c.id
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -55,7 +66,7 @@ func (c *Client) readPump() {
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadLimit(c.hub.maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
@@ -67,7 +78,7 @@ func (c *Client) readPump() {
 
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("UnexpectedCloseError: %v\n", err)
+				c.hub.logger.Printf("UnexpectedCloseError: %v\n", err)
 			}
 			break
 		}
@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Conn is a single websocket connection handled by Handler.
+type Conn struct {
+	conn    *websocket.Conn
+	request *http.Request
+}
+
+// Returns the HTTP request that was upgraded to this connection.
+func (c *Conn) Request() *http.Request {
+	return c.request
+}
+
+// Read the next message and decode it as JSON into v.
+func (c *Conn) ReadJSON(v any) error {
+	return c.conn.ReadJSON(v)
+}
+
+// Encode v as JSON and send it as a text message.
+func (c *Conn) WriteJSON(v any) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteJSON(v)
+}
+
+// Read the next message as text.
+func (c *Conn) ReadText() (string, error) {
+	_, message, err := c.conn.ReadMessage()
+	return string(message), err
+}
+
+// Send text as a text message.
+func (c *Conn) WriteText(text string) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(websocket.TextMessage, []byte(text))
+}
+
+// Close the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+/*
+Handler upgrades each request to a websocket connection and calls onConnect
+with it in the request's goroutine. The connection is closed when onConnect returns.
+Use it for per-connection request/response endpoints that don't need the broadcast hub.
+
+	r.GET("/echo", gora.WrapH(ws.Handler(func(conn *ws.Conn) {
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			conn.WriteJSON(msg)
+		}
+	})))
+*/
+func Handler(onConnect func(conn *Conn)) http.Handler {
+	upgrader := websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return true
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		c := &Conn{conn: conn, request: r}
+		defer c.Close()
+
+		conn.SetReadLimit(maxMessageSize)
+		onConnect(c)
+	})
+}
@@ -23,11 +23,13 @@ func (dialer *DefaultDialer) dial() (conn *websocket.Conn, err error) {
 }
 
 // Send message in text format to all websocket clients
+// The connection is closed once the message is sent. Use Connect to keep it open.
 func (dialer *DefaultDialer) Send(data any) error {
 	conn, err := dialer.dial()
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
 	w, err := conn.NextWriter(websocket.TextMessage)
 	if err != nil {
@@ -37,3 +39,32 @@ func (dialer *DefaultDialer) Send(data any) error {
 	defer w.Close()
 	return json.NewEncoder(w).Encode(data)
 }
+
+/*
+Connect opens a connection to the server that stays open to send and receive messages
+e.g for bots and integration tests. Callers must close the returned connection.
+
+	conn, err := dialer.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for {
+		message, err := dialer.Receive(conn)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(message))
+	}
+*/
+func (dialer *DefaultDialer) Connect() (*websocket.Conn, error) {
+	return dialer.dial()
+}
+
+// Blocks until the next message is received on conn and returns it.
+// Returns an error once the connection is closed.
+func (dialer *DefaultDialer) Receive(conn *websocket.Conn) ([]byte, error) {
+	_, message, err := conn.ReadMessage()
+	return message, err
+}
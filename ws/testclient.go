@@ -0,0 +1,89 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Time TestClient.Expect waits for a message before failing the test.
+var TestClientTimeout = 5 * time.Second
+
+// TestClient is a websocket client connected to a handler served by an httptest.Server.
+// Use it to write concise tests for websocket handlers.
+type TestClient struct {
+	t    testing.TB
+	conn *websocket.Conn
+}
+
+/*
+NewTestClient serves handler with an httptest.Server and returns a client connected to it.
+The connection and server are closed when the test completes.
+The test fails immediately if the client can not connect.
+
+	hub, quit := ws.NewHandler()
+	defer quit()
+	go hub.Run()
+
+	client := ws.NewTestClient(t, hub)
+	client.Send("hello")
+	client.Expect("hello")
+*/
+func NewTestClient(t testing.TB, handler http.Handler) *TestClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		server.Close()
+		t.Fatalf("ws: unable to connect test client: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		server.Close()
+	})
+	return &TestClient{t: t, conn: conn}
+}
+
+// Send message as a text message. Fails the test if the write fails.
+func (c *TestClient) Send(message string) {
+	c.t.Helper()
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+		c.t.Fatalf("ws: unable to send message: %v", err)
+	}
+}
+
+// Receive the next message. Fails the test if no message arrives within TestClientTimeout.
+func (c *TestClient) Receive() string {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+	_, message, err := c.conn.ReadMessage()
+	if err != nil {
+		c.t.Fatalf("ws: unable to receive message: %v", err)
+	}
+	return string(message)
+}
+
+// Receive the next message and fail the test if it is not expected.
+func (c *TestClient) Expect(expected string) {
+	c.t.Helper()
+
+	if message := c.Receive(); message != expected {
+		c.t.Fatalf("ws: expected message %q, got %q", expected, message)
+	}
+}
+
+// Close the connection before the test completes e.g to test disconnects.
+func (c *TestClient) Close() error {
+	return c.conn.Close()
+}
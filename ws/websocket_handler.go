@@ -2,9 +2,14 @@
 package ws
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
 )
 
 /*
@@ -24,8 +29,15 @@ routes. Don't forget to call the Run method in a seperate go routine.
 	log.Fatal(http.ListenAndServe(":8080", nil))
 */
 type WebsocketHandler struct {
+	// Guards clients, clientsByID and rooms.
+	mu sync.Mutex
 	// Registered clients.
 	clients map[*Client]bool
+	// Registered clients with an id, keyed by id.
+	// A client id may have several connections e.g a user with many tabs.
+	clientsByID map[string]map[*Client]bool
+	// Members of each room, keyed by room name.
+	rooms map[string]map[*Client]bool
 	// Inbound messages from the clients.
 	broadcast chan []byte
 	// Register requests from the clients.
@@ -39,8 +51,57 @@ type WebsocketHandler struct {
 
 	// Broadcast Message to all connected clients?.
 	broadcastMessages bool
+
+	// Capacity of each client's buffered send channel.
+	sendBufferSize int
+
+	// Logger for hub events. Defaults to log.Default().
+	logger *log.Logger
+
+	// Number of clients dropped because their send buffer was full.
+	droppedClients atomic.Uint64
+
+	// Origin check used when upgrading connections. Allows all origins by default.
+	checkOrigin func(r *http.Request) bool
+
+	// Returns the id of the client connecting with the request.
+	clientID func(r *http.Request) string
+
+	// Called for each new client e.g to join rooms.
+	onConnect func(client *Client, r *http.Request)
+
+	// I/O buffer sizes of the upgrader in bytes.
+	// Zero uses the buffers allocated by the HTTP server.
+	readBufferSize, writeBufferSize int
+
+	// Maximum size in bytes of a message read from a client.
+	maxMessageSize int64
+
+	// Upgrader configured from the hub's options.
+	upgrader websocket.Upgrader
+
+	// Ring buffer of the last broadcast messages replayed to new clients.
+	// Guarded by mu. See WithHistory.
+	history      [][]byte
+	historyStart int
+	historyLen   int
 }
 
+var (
+	ErrClientNotFound = errors.New("websocket client not found")
+	ErrSendBufferFull = errors.New("websocket client send buffer full")
+)
+
+// Returns the client id from the client_id query parameter or the X-Client-ID header.
+func defaultClientID(r *http.Request) string {
+	if id := r.URL.Query().Get("client_id"); id != "" {
+		return id
+	}
+	return r.Header.Get("X-Client-ID")
+}
+
+// Configures a WebsocketHandler created with NewHandler.
+// Options ignore invalid values (e.g sizes less than 1), keeping the default.
 type HubOption func(*WebsocketHandler)
 
 func OnMessage(f func(msg []byte)) HubOption {
@@ -55,6 +116,101 @@ func NoBroadcast() HubOption {
 	}
 }
 
+/*
+Set the capacity of each client's send buffer. Defaults to 256 messages.
+
+A larger buffer lets slow clients absorb bursts of broadcasts before being dropped,
+at the cost of holding up to n queued messages in memory per connection.
+A smaller buffer keeps memory per connection low but drops slow clients sooner.
+When a client's buffer is full, the client is dropped: its connection is closed
+and DroppedClients is incremented. Values less than 1 are ignored.
+*/
+func WithSendBuffer(n int) HubOption {
+	return func(h *WebsocketHandler) {
+		if n > 0 {
+			h.sendBufferSize = n
+		}
+	}
+}
+
+// Set the logger used by the hub. Defaults to log.Default().
+// A nil logger is ignored.
+func WithLogger(logger *log.Logger) HubOption {
+	return func(h *WebsocketHandler) {
+		if logger != nil {
+			h.logger = logger
+		}
+	}
+}
+
+// Set the function that validates the Origin header of upgrade requests.
+// Connections are rejected with 403 Forbidden if it returns false.
+// By default, all origins are allowed.
+//
+//	ws.WithCheckOrigin(func(r *http.Request) bool {
+//		return r.Header.Get("Origin") == "https://example.com"
+//	})
+func WithCheckOrigin(checkOrigin func(r *http.Request) bool) HubOption {
+	return func(h *WebsocketHandler) {
+		h.checkOrigin = checkOrigin
+	}
+}
+
+// Set the function that identifies the client connecting with a request
+// e.g from the authenticated user. Messages are sent to clients by id with SendTo.
+// By default, the id is read from the client_id query parameter or the X-Client-ID header.
+func WithClientID(clientID func(r *http.Request) string) HubOption {
+	return func(h *WebsocketHandler) {
+		h.clientID = clientID
+	}
+}
+
+// Call f with each new client and the upgraded request
+// e.g to Join the client to rooms based on the request.
+func OnConnect(f func(client *Client, r *http.Request)) HubOption {
+	return func(h *WebsocketHandler) {
+		h.onConnect = f
+	}
+}
+
+// Set the I/O buffer sizes in bytes used when upgrading connections.
+// The buffer sizes do not limit the size of messages. Defaults to 0,
+// reusing the buffers allocated by the HTTP server. Negative values are ignored.
+func WithBufferSizes(read, write int) HubOption {
+	return func(h *WebsocketHandler) {
+		if read >= 0 {
+			h.readBufferSize = read
+		}
+		if write >= 0 {
+			h.writeBufferSize = write
+		}
+	}
+}
+
+// Set the maximum size in bytes of a message read from a client. Defaults to 10MB.
+// Clients sending larger messages are disconnected. Values less than 1 are ignored.
+func WithMaxMessageSize(n int64) HubOption {
+	return func(h *WebsocketHandler) {
+		if n > 0 {
+			h.maxMessageSize = n
+		}
+	}
+}
+
+// Keep the last n messages sent with BroadCastMessage and replay them,
+// oldest first, to each new client before any live message.
+// History that does not fit in the client's send buffer is not replayed.
+// Like other queued messages, replayed messages may be written in a single
+// frame separated by newlines.
+// Values less than 1 are ignored, leaving history disabled (the default).
+func WithHistory(n int) HubOption {
+	return func(h *WebsocketHandler) {
+		if n > 0 {
+			h.history = make([][]byte, n)
+		}
+	}
+}
+
 // Returns a new websocker hundler.
 // By default, this handler broadcasts all messages to connected clients
 // as in a chat. If you want to handle each message yourself, pass in an OnMessage Option and NoBroadcast option.
@@ -65,15 +221,30 @@ func NewHandler(options ...HubOption) (handler *WebsocketHandler, quit func()) {
 		register:          make(chan *Client),
 		unregister:        make(chan *Client),
 		clients:           make(map[*Client]bool),
+		clientsByID:       make(map[string]map[*Client]bool),
+		rooms:             make(map[string]map[*Client]bool),
 		onmessage:         nil,
 		done:              make(chan struct{}),
 		broadcastMessages: true,
+		sendBufferSize:    defaultSendBufferSize,
+		logger:            log.Default(),
+		checkOrigin: func(r *http.Request) bool {
+			return true
+		},
+		clientID:       defaultClientID,
+		maxMessageSize: maxMessageSize,
 	}
 
 	for _, opt := range options {
 		opt(h)
 	}
 
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  h.readBufferSize,
+		WriteBufferSize: h.writeBufferSize,
+		CheckOrigin:     h.checkOrigin,
+	}
+
 	// Function to close the hub
 	var once sync.Once
 	closeFunc := func() {
@@ -87,68 +258,251 @@ func NewHandler(options ...HubOption) (handler *WebsocketHandler, quit func()) {
 // Infinite loop that runs the hub indefinately.
 // Run this is a go routine.
 func (h *WebsocketHandler) Run() {
+	h.RunContext(context.Background())
+}
+
+// Like Run but also returns when ctx is cancelled, closing all clients.
+// Useful to tie the hub's lifetime to the rest of the application e.g with errgroup.
+func (h *WebsocketHandler) RunContext(ctx context.Context) {
 	for {
 		select {
 		case client := <-h.register:
-			h.clients[client] = true
+			h.addClient(client)
 		case client := <-h.unregister:
+			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
 				h.removeClient(client)
 			}
+			h.mu.Unlock()
 		case message := <-h.broadcast:
 			h.BroadCastMessage(message)
 			if h.onmessage != nil {
 				h.onmessage(message)
 			}
 		case <-h.done:
-			// remove all clients and return
-			for c := range h.clients {
-				h.removeClient(c)
-			}
-			log.Println("quitting websocket run loop gracefully")
+			h.shutdown()
+			return
+		case <-ctx.Done():
+			h.shutdown()
 			return
 		}
 	}
 }
 
+// Removes all clients when the run loop exits.
+func (h *WebsocketHandler) shutdown() {
+	h.mu.Lock()
+	for c := range h.clients {
+		h.removeClient(c)
+	}
+	h.mu.Unlock()
+	h.logger.Println("quitting websocket run loop gracefully")
+}
+
 // send message to all active clients.
 // Client who can't recv are closed and deleted from the client map
 func (h *WebsocketHandler) BroadCastMessage(message []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.recordHistory(message)
 	for client := range h.clients {
 		select {
 		case client.send <- message:
 		default:
-			h.removeClient(client)
+			h.dropClient(client)
+		}
+	}
+}
+
+// Send message to the clients connected with clientID.
+// Returns ErrClientNotFound if no client is connected with the id and
+// ErrSendBufferFull if the message could not be queued for any of them.
+// Clients whose send buffer is full are dropped as in BroadCastMessage.
+func (h *WebsocketHandler) SendTo(clientID string, message []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	clients := h.clientsByID[clientID]
+	if len(clients) == 0 {
+		return ErrClientNotFound
+	}
+
+	var sent bool
+	for client := range clients {
+		select {
+		case client.send <- message:
+			sent = true
+		default:
+			h.dropClient(client)
 		}
 	}
+
+	if !sent {
+		return ErrSendBufferFull
+	}
+	return nil
+}
+
+// Add client to room. Broadcasts to the room with BroadcastToRoom reach all its members.
+// Clients leave all their rooms when they disconnect.
+func (h *WebsocketHandler) Join(client *Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The client disconnected and its send channel is closed.
+	if client.closed {
+		return
+	}
+
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*Client]bool)
+	}
+	h.rooms[room][client] = true
+
+	if client.rooms == nil {
+		client.rooms = make(map[string]bool)
+	}
+	client.rooms[room] = true
+}
+
+// Remove client from room.
+func (h *WebsocketHandler) Leave(client *Client, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(client, room)
+}
+
+// Must be called with h.mu held.
+func (h *WebsocketHandler) leave(client *Client, room string) {
+	delete(client.rooms, room)
+	if members, ok := h.rooms[room]; ok {
+		delete(members, client)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+// Send message to all members of room.
+// Clients whose send buffer is full are dropped as in BroadCastMessage.
+func (h *WebsocketHandler) BroadcastToRoom(room string, message []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.rooms[room] {
+		select {
+		case client.send <- message:
+		default:
+			h.dropClient(client)
+		}
+	}
+}
+
+// Drops a client whose send buffer is full, logging the number of queued
+// messages and incrementing the dropped clients counter.
+func (h *WebsocketHandler) dropClient(client *Client) {
+	queued := len(client.send)
+	h.droppedClients.Add(1)
+	h.logger.Printf("dropping slow websocket client: send buffer full (%d/%d messages queued)\n",
+		queued, cap(client.send))
+	h.removeClient(client)
 }
 
+// Returns the total number of clients dropped because their send buffer was full.
+func (h *WebsocketHandler) DroppedClients() uint64 {
+	return h.droppedClients.Load()
+}
+
+// Appends message to the history, overwriting the oldest message when full.
+// Must be called with h.mu held.
+func (h *WebsocketHandler) recordHistory(message []byte) {
+	size := len(h.history)
+	if size == 0 {
+		return
+	}
+
+	h.history[(h.historyStart+h.historyLen)%size] = message
+	if h.historyLen < size {
+		h.historyLen++
+	} else {
+		h.historyStart = (h.historyStart + 1) % size
+	}
+}
+
+// Queues the history for client, oldest first, without blocking.
+// Must be called with h.mu held.
+func (h *WebsocketHandler) replayHistory(client *Client) {
+	for i := 0; i < h.historyLen; i++ {
+		select {
+		case client.send <- h.history[(h.historyStart+i)%len(h.history)]:
+		default:
+			return
+		}
+	}
+}
+
+func (h *WebsocketHandler) addClient(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Dropped (e.g from a room) before it was registered.
+	if client.closed {
+		return
+	}
+
+	h.replayHistory(client)
+	h.clients[client] = true
+	if client.id == "" {
+		return
+	}
+
+	if h.clientsByID[client.id] == nil {
+		h.clientsByID[client.id] = make(map[*Client]bool)
+	}
+	h.clientsByID[client.id][client] = true
+}
+
+// Closes the client's send channel and deletes it from the hub.
+// Must be called with h.mu held.
 func (h *WebsocketHandler) removeClient(client *Client) {
 	close(client.send)
+	client.closed = true
 	delete(h.clients, client)
+
+	for room := range client.rooms {
+		h.leave(client, room)
+	}
+
+	if clients, ok := h.clientsByID[client.id]; ok {
+		delete(clients, client)
+		if len(clients) == 0 {
+			delete(h.clientsByID, client.id)
+		}
+	}
 }
 
 // Http handler
 func (hub *WebsocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	upgrader.CheckOrigin = func(r *http.Request) bool {
-		return true
-	}
-
 	// Upgrade upgrades the HTTP server connection to the WebSocket protocol.
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := hub.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
 	}
 
-	// could pass more client specific identifiers from request like client_id, authentication etc
 	client := &Client{
 		hub:  hub,
 		conn: conn,
-		send: make(chan []byte, 256),
+		send: make(chan []byte, hub.sendBufferSize),
+		id:   hub.clientID(r),
 	}
 
 	client.hub.register <- client
 
+	if hub.onConnect != nil {
+		hub.onConnect(client, r)
+	}
+
 	go client.writePump()
 	go client.readPump()
 }
@@ -0,0 +1,430 @@
+package ws
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abiiranathan/gora/gora"
+	"github.com/gorilla/websocket"
+)
+
+func TestBroadcast(t *testing.T) {
+	hub, quit := NewHandler()
+	defer quit()
+	go hub.Run()
+
+	receiver := NewTestClient(t, hub)
+	sender := NewTestClient(t, hub)
+
+	sender.Send("hello")
+	sender.Expect("hello")
+	receiver.Expect("hello")
+}
+
+func TestOnMessage(t *testing.T) {
+	received := make(chan string, 1)
+
+	hub, quit := NewHandler(NoBroadcast(), OnMessage(func(msg []byte) {
+		received <- string(msg)
+	}))
+	defer quit()
+	go hub.Run()
+
+	client := NewTestClient(t, hub)
+	client.Send("  hello  ")
+
+	select {
+	case msg := <-received:
+		if msg != "hello" {
+			t.Errorf("expected trimmed message %q, got %q", "hello", msg)
+		}
+	case <-time.After(TestClientTimeout):
+		t.Fatal("OnMessage was not called")
+	}
+}
+
+func TestWithCheckOrigin(t *testing.T) {
+	hub, quit := NewHandler(WithCheckOrigin(func(r *http.Request) bool {
+		return r.Header.Get("Origin") == "https://example.com"
+	}))
+	defer quit()
+	go hub.Run()
+
+	server := httptest.NewServer(hub)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.com"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected connection from disallowed origin to be rejected with 403, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://example.com"}})
+	if err != nil {
+		t.Fatalf("expected connection from allowed origin, got %v", err)
+	}
+	conn.Close()
+}
+
+func TestSendTo(t *testing.T) {
+	hub, quit := NewHandler(NoBroadcast())
+	defer quit()
+	go hub.Run()
+
+	server := httptest.NewServer(hub)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	alice, _, err := websocket.DefaultDialer.Dial(url+"?client_id=alice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Close()
+
+	bob, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Client-ID": {"bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bob.Close()
+
+	// Clients are registered asynchronously after the upgrade.
+	sendTo := func(id, message string) {
+		deadline := time.Now().Add(TestClientTimeout)
+		for {
+			err := hub.SendTo(id, []byte(message))
+			if err == nil {
+				return
+			}
+
+			if err != ErrClientNotFound || time.Now().After(deadline) {
+				t.Fatalf("SendTo(%s): %v", id, err)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	sendTo("alice", "hello alice")
+	sendTo("bob", "hello bob")
+
+	for conn, expected := range map[*websocket.Conn]string{alice: "hello alice", bob: "hello bob"} {
+		conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(message) != expected {
+			t.Errorf("expected %q, got %q", expected, message)
+		}
+	}
+
+	if err := hub.SendTo("carol", []byte("hello")); err != ErrClientNotFound {
+		t.Errorf("expected ErrClientNotFound, got %v", err)
+	}
+}
+
+func TestRooms(t *testing.T) {
+	joined := make(chan struct{}, 3)
+	hub, quit := NewHandler(NoBroadcast(), OnConnect(func(client *Client, r *http.Request) {
+		client.hub.Join(client, r.URL.Query().Get("room"))
+		joined <- struct{}{}
+	}))
+	defer quit()
+	go hub.Run()
+
+	server := httptest.NewServer(hub)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dial := func(room string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?room="+room, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+
+	general1, general2, random := dial("general"), dial("general"), dial("random")
+	for i := 0; i < 3; i++ {
+		<-joined
+	}
+
+	hub.BroadcastToRoom("general", []byte("hello general"))
+	hub.BroadcastToRoom("random", []byte("hello random"))
+
+	for conn, expected := range map[*websocket.Conn]string{
+		general1: "hello general",
+		general2: "hello general",
+		random:   "hello random",
+	} {
+		conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(message) != expected {
+			t.Errorf("expected %q, got %q", expected, message)
+		}
+	}
+}
+
+func TestDialerConnectAndReceive(t *testing.T) {
+	hub, quit := NewHandler()
+	defer quit()
+	go hub.Run()
+
+	server := httptest.NewServer(hub)
+	defer server.Close()
+
+	dialer := NewDialer("ws" + strings.TrimPrefix(server.URL, "http"))
+	conn, err := dialer.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+	message, err := dialer.Receive(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(message) != "hello" {
+		t.Errorf("expected hello, got %q", message)
+	}
+}
+
+func TestWithMaxMessageSize(t *testing.T) {
+	hub, quit := NewHandler(WithMaxMessageSize(8), WithBufferSizes(1024, 1024))
+	defer quit()
+	go hub.Run()
+
+	client := NewTestClient(t, hub)
+	client.Send("small")
+	client.Expect("small")
+
+	// The server closes the connection after reading a message over the limit.
+	client.Send("this message is too large")
+	client.conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+	if _, _, err := client.conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+		t.Errorf("expected close error %d, got %v", websocket.CloseMessageTooBig, err)
+	}
+}
+
+func TestWithHistory(t *testing.T) {
+	hub, quit := NewHandler(WithHistory(2))
+	defer quit()
+	go hub.Run()
+
+	sender := NewTestClient(t, hub)
+	for _, message := range []string{"one", "two", "three"} {
+		sender.Send(message)
+		sender.Expect(message)
+	}
+
+	// Joins after the broadcasts and receives the last 2, then live messages.
+	// Queued messages may arrive in a single newline-separated frame.
+	late := NewTestClient(t, hub)
+	var replayed []string
+	for len(replayed) < 2 {
+		replayed = append(replayed, strings.Split(late.Receive(), "\n")...)
+	}
+
+	if strings.Join(replayed, ",") != "two,three" {
+		t.Fatalf("expected history two,three, got %v", replayed)
+	}
+
+	sender.Send("four")
+	late.Expect("four")
+}
+
+// Registers a client without read and write pumps so that its send buffer fills up.
+func addIdleClient(hub *WebsocketHandler) *Client {
+	client := &Client{hub: hub, send: make(chan []byte, hub.sendBufferSize)}
+	hub.addClient(client)
+	return client
+}
+
+func TestWithLoggerAndDroppedClients(t *testing.T) {
+	var logs bytes.Buffer
+	hub, quit := NewHandler(WithLogger(log.New(&logs, "", 0)), WithSendBuffer(1))
+	defer quit()
+
+	addIdleClient(hub)
+	hub.BroadCastMessage([]byte("one"))
+	if hub.DroppedClients() != 0 {
+		t.Fatalf("expected no dropped clients, got %d", hub.DroppedClients())
+	}
+
+	hub.BroadCastMessage([]byte("two"))
+	if hub.DroppedClients() != 1 {
+		t.Errorf("expected 1 dropped client, got %d", hub.DroppedClients())
+	}
+
+	if !strings.Contains(logs.String(), "dropping slow websocket client: send buffer full (1/1 messages queued)") {
+		t.Errorf("expected drop to be logged to the configured logger, got %q", logs.String())
+	}
+
+	// A nil logger keeps the default instead of panicking on first use.
+	hub, quit = NewHandler(WithLogger(nil))
+	defer quit()
+
+	if hub.logger != log.Default() {
+		t.Error("expected nil logger to be ignored")
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	hub, quit := NewHandler()
+	defer quit()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		hub.RunContext(ctx)
+		close(stopped)
+	}()
+
+	client := NewTestClient(t, hub)
+	client.Send("hello")
+	client.Expect("hello")
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(TestClientTimeout):
+		t.Fatal("expected RunContext to return when the context is cancelled")
+	}
+
+	client.conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+	if _, _, err := client.conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNoStatusReceived) {
+		t.Errorf("expected the client to be closed, got %v", err)
+	}
+}
+
+func TestWithSendBuffer(t *testing.T) {
+	hub, quit := NewHandler(WithSendBuffer(2), WithLogger(log.New(io.Discard, "", 0)))
+	defer quit()
+
+	slow := addIdleClient(hub)
+	if cap(slow.send) != 2 {
+		t.Fatalf("expected send buffer of 2, got %d", cap(slow.send))
+	}
+
+	hub.BroadCastMessage([]byte("one"))
+	hub.BroadCastMessage([]byte("two"))
+	if slow.closed {
+		t.Fatal("expected client to be kept while its buffer has room")
+	}
+
+	// The third message does not fit: the client is dropped and its send channel closed.
+	hub.BroadCastMessage([]byte("three"))
+	if !slow.closed || hub.DroppedClients() != 1 {
+		t.Fatalf("expected slow client to be dropped, closed=%v dropped=%d", slow.closed, hub.DroppedClients())
+	}
+
+	var queued []string
+	for message := range slow.send {
+		queued = append(queued, string(message))
+	}
+
+	if strings.Join(queued, ",") != "one,two" {
+		t.Errorf("expected queued messages one,two, got %v", queued)
+	}
+
+	if _, ok := hub.clients[slow]; ok {
+		t.Error("expected dropped client to be removed from the hub")
+	}
+
+	for _, n := range []int{0, -1} {
+		hub, quit := NewHandler(WithSendBuffer(n), WithLogger(log.New(io.Discard, "", 0)))
+		quit()
+
+		if hub.sendBufferSize != defaultSendBufferSize {
+			t.Errorf("expected WithSendBuffer(%d) to keep the default of %d, got %d", n, defaultSendBufferSize, hub.sendBufferSize)
+		}
+	}
+}
+
+func TestHandlerThroughRouter(t *testing.T) {
+	r := gora.New(io.Discard)
+	r.GET("/echo", gora.WrapH(Handler(func(conn *Conn) {
+		if conn.Request().URL.Query().Get("room") != "general" {
+			conn.WriteText("missing room")
+			return
+		}
+
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			conn.WriteJSON(msg)
+		}
+	})))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/echo"
+
+	// The connection is closed when the callback returns.
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+	if _, message, err := conn.ReadMessage(); err != nil || string(message) != "missing room" {
+		t.Fatalf("expected missing room, got %q %v", message, err)
+	}
+
+	if _, _, err := conn.ReadMessage(); err == nil {
+		t.Error("expected the connection to be closed after the callback returns")
+	}
+
+	conn, _, err = websocket.DefaultDialer.Dial(url+"?room=general", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(map[string]any{"text": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var reply map[string]any
+	conn.SetReadDeadline(time.Now().Add(TestClientTimeout))
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+
+	if reply["text"] != "hello" {
+		t.Errorf("expected echoed message, got %v", reply)
+	}
+
+	// A plain HTTP request is not upgraded.
+	resp, err := http.Get(server.URL + "/echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a non-websocket request, got %d", resp.StatusCode)
+	}
+}